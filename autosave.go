@@ -0,0 +1,187 @@
+package chromem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// autoSave holds the state of a running auto-save goroutine.
+type autoSave struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// EnableAutoSave makes the collection track added documents as dirty instead of
+// writing them to disk right away, and starts a goroutine that writes the dirty
+// documents to disk every interval. This is useful when adding many documents
+// one by one, because the file writes happen in the background. But until the
+// next flush, added documents are only kept in memory, unless the write-ahead
+// log is enabled (see [Collection.EnableWAL]).
+//
+// Deleted documents are still removed from disk right away. If flushing fails,
+// the documents stay dirty and are retried with the next flush. Use
+// [Collection.Flush] to write the dirty documents immediately and
+// [Collection.DisableAutoSave] to stop the goroutine.
+//
+// Calling it again restarts the goroutine with the new interval.
+// Only works for persistent collections.
+func (c *Collection) EnableAutoSave(interval time.Duration) error {
+	if c.persistDirectory == "" {
+		return errors.New("collection is not persistent")
+	}
+	if interval <= 0 {
+		return errors.New("interval must be positive")
+	}
+
+	c.autoSaveLock.Lock()
+	defer c.autoSaveLock.Unlock()
+
+	c.stopAutoSave()
+
+	c.documentsLock.Lock()
+	c.deferWrites = true
+	c.documentsLock.Unlock()
+
+	as := &autoSave{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	c.autoSave = as
+	go func() {
+		defer close(as.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-as.stop:
+				return
+			case <-ticker.C:
+				// Errors are ignored, the documents stay dirty and are retried
+				// with the next tick.
+				_ = c.Flush(context.Background())
+			}
+		}
+	}()
+
+	return nil
+}
+
+// DisableAutoSave stops the auto-save goroutine started by
+// [Collection.EnableAutoSave] and writes the remaining dirty documents to disk.
+// From now on, added documents are written to disk right away again.
+// It's a no-op if auto-save isn't enabled.
+func (c *Collection) DisableAutoSave() error {
+	c.autoSaveLock.Lock()
+	defer c.autoSaveLock.Unlock()
+
+	if c.autoSave == nil {
+		return nil
+	}
+	c.stopAutoSave()
+
+	c.documentsLock.Lock()
+	c.deferWrites = false
+	c.documentsLock.Unlock()
+
+	return c.Flush(context.Background())
+}
+
+// stopAutoSave stops the auto-save goroutine and waits for it to finish.
+// The caller must hold the auto-save lock.
+func (c *Collection) stopAutoSave() {
+	if c.autoSave == nil {
+		return
+	}
+	close(c.autoSave.stop)
+	<-c.autoSave.done
+	c.autoSave = nil
+}
+
+// discardAutoSave stops the auto-save goroutine without flushing and discards
+// the dirty state. It's used when the collection is deleted.
+func (c *Collection) discardAutoSave() {
+	c.autoSaveLock.Lock()
+	c.stopAutoSave()
+	c.autoSaveLock.Unlock()
+
+	c.dirtyLock.Lock()
+	c.dirty = nil
+	c.dirtyLock.Unlock()
+}
+
+// IsDirty returns true if the collection contains documents that were added or
+// updated but not written to disk yet. This can only be the case when auto-save
+// is enabled via [Collection.EnableAutoSave].
+func (c *Collection) IsDirty() bool {
+	c.dirtyLock.Lock()
+	defer c.dirtyLock.Unlock()
+	return len(c.dirty) > 0
+}
+
+// Flush writes the dirty documents to disk. It's a no-op if there are none.
+// See [Collection.EnableAutoSave].
+func (c *Collection) Flush(ctx context.Context) error {
+	end, err := c.beginOp(true)
+	if err != nil {
+		return err
+	}
+	defer end()
+
+	// The read lock prevents concurrent adds and deletes, so the dirty documents
+	// can't change while we write them, and deleted documents can't be written
+	// to disk again.
+	c.documentsLock.RLock()
+	defer c.documentsLock.RUnlock()
+
+	c.dirtyLock.Lock()
+	ids := make([]string, 0, len(c.dirty))
+	for id := range c.dirty {
+		ids = append(ids, id)
+	}
+	c.dirtyLock.Unlock()
+
+	// Documents are only unmarked after they're written, so that they're
+	// reported as dirty until then.
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if doc, ok := c.documents[id]; ok {
+			err := c.persistDocument(doc)
+			if err != nil {
+				return fmt.Errorf("couldn't flush document '%s': %w", id, err)
+			}
+			// The document might have been moved back from the cold tier, whose
+			// file is only removed after the hot one is written.
+			if c.cold != nil {
+				err = c.removeColdDocumentFile(id)
+				if err != nil {
+					return fmt.Errorf("couldn't flush document '%s': %w", id, err)
+				}
+			}
+		}
+		c.unmarkDirty(id)
+	}
+
+	return nil
+}
+
+// markDirty marks the document with the given ID as dirty.
+func (c *Collection) markDirty(id string) {
+	c.dirtyLock.Lock()
+	defer c.dirtyLock.Unlock()
+	if c.dirty == nil {
+		c.dirty = make(map[string]struct{})
+	}
+	c.dirty[id] = struct{}{}
+}
+
+// unmarkDirty removes the document with the given ID from the dirty documents,
+// for example because it was deleted.
+func (c *Collection) unmarkDirty(id string) {
+	c.dirtyLock.Lock()
+	defer c.dirtyLock.Unlock()
+	delete(c.dirty, id)
+}
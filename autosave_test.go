@@ -0,0 +1,164 @@
+package chromem
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCollection_EnableAutoSave(t *testing.T) {
+	ctx := context.Background()
+	name := "test"
+	vectors := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return vectors, nil
+	}
+
+	tempDir := t.TempDir()
+	db, err := NewPersistentDB(tempDir, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c, err := db.CreateCollection(name, nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	// Long enough interval to not be flushed in between
+	err = c.EnableAutoSave(time.Hour)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	t.Cleanup(func() { _ = c.DisableAutoSave() })
+	err = c.AddDocuments(ctx, []Document{
+		{ID: "1", Content: "foo"},
+		{ID: "2", Content: "bar"},
+	}, 1)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if !c.IsDirty() {
+		t.Fatal("expected collection to be dirty")
+	}
+	for _, id := range []string{"1", "2"} {
+		if _, err := os.Stat(c.getDocPath(id)); !os.IsNotExist(err) {
+			t.Fatal("expected document file to not exist yet, got", err)
+		}
+	}
+
+	// Flush
+	err = c.Flush(ctx)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if c.IsDirty() {
+		t.Fatal("expected collection to not be dirty")
+	}
+	for _, id := range []string{"1", "2"} {
+		if _, err := os.Stat(c.getDocPath(id)); err != nil {
+			t.Fatal("expected document file to exist, got", err)
+		}
+	}
+
+	// Auto-save
+	interval := 50 * time.Millisecond
+	err = c.EnableAutoSave(interval)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.AddDocument(ctx, Document{ID: "3", Content: "baz"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if !c.IsDirty() {
+		t.Fatal("expected collection to be dirty")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for c.IsDirty() && time.Now().Before(deadline) {
+		time.Sleep(interval)
+	}
+	if c.IsDirty() {
+		t.Fatal("expected collection to not be dirty after auto-save")
+	}
+	if _, err := os.Stat(c.getDocPath("3")); err != nil {
+		t.Fatal("expected document file to exist, got", err)
+	}
+
+	// Deleted dirty documents must not be written
+	err = c.EnableAutoSave(time.Hour)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.AddDocument(ctx, Document{ID: "4", Content: "qux"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.Delete(ctx, nil, nil, "4")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if c.IsDirty() {
+		t.Fatal("expected collection to not be dirty after delete")
+	}
+
+	// Disabling writes the remaining documents
+	err = c.AddDocument(ctx, Document{ID: "5", Content: "quux"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.DisableAutoSave()
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if c.IsDirty() {
+		t.Fatal("expected collection to not be dirty")
+	}
+
+	// And then documents are written right away again
+	err = c.AddDocument(ctx, Document{ID: "6", Content: "corge"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if c.IsDirty() {
+		t.Fatal("expected collection to not be dirty")
+	}
+
+	// All documents are loaded by a new DB
+	db2, err := NewPersistentDB(tempDir, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c2 := db2.GetCollection(name, nil)
+	if c2 == nil {
+		t.Fatal("expected collection, got nil")
+	}
+	if c2.Count() != 5 {
+		t.Fatal("expected 5 documents, got", c2.Count())
+	}
+}
+
+func TestCollection_EnableAutoSave_Errors(t *testing.T) {
+	c, err := NewDB().CreateCollection("test", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.EnableAutoSave(time.Second)
+	if err == nil {
+		t.Fatal("expected error for non-persistent collection, got nil")
+	}
+
+	db, err := NewPersistentDB(filepath.Join(t.TempDir(), "db"), false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c, err = db.CreateCollection("test", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.EnableAutoSave(0)
+	if err == nil {
+		t.Fatal("expected error for zero interval, got nil")
+	}
+}
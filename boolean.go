@@ -0,0 +1,111 @@
+package chromem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// DocumentFilter is a filter on a document's metadata and content. A document
+// matches if it matches both Where and WhereDocument, which work like the where
+// and whereDocument parameters of [Collection.Query]. An empty filter matches
+// all documents.
+type DocumentFilter struct {
+	Where         map[string]string
+	WhereDocument map[string]string
+}
+
+// BooleanFilter combines document filters like a boolean query in
+// Elasticsearch. A document matches if:
+//
+//   - it matches all Must filters,
+//   - it matches at least MinimumShouldMatch of the Should filters,
+//   - and it matches none of the MustNot filters.
+//
+// The filters only decide which documents are included, they don't influence
+// the similarity. So unlike in Elasticsearch, the Should filters don't have an
+// effect if MinimumShouldMatch is 0. An empty BooleanFilter matches all
+// documents.
+type BooleanFilter struct {
+	Must               []DocumentFilter
+	Should             []DocumentFilter
+	MustNot            []DocumentFilter
+	MinimumShouldMatch int
+}
+
+// QueryBoolean performs an exhaustive nearest neighbor search on the documents
+// of the collection that match the boolean filter.
+//
+//   - queryText: The text to search for. Its embedding will be created using the
+//     collection's embedding function.
+//   - nResults: The maximum number of results to return. Must be > 0.
+//     There can be fewer results if the filter excludes documents.
+//   - filter: The filter that documents must match to be included.
+func (c *Collection) QueryBoolean(ctx context.Context, queryText string, nResults int, filter BooleanFilter) ([]Result, error) {
+	if queryText == "" {
+		return nil, errors.New("queryText is empty")
+	}
+	err := filter.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	queryVector, err := c.embed(ctx, queryText)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create embedding of query: %w", err)
+	}
+
+	return c.queryEmbeddingFunc(ctx, queryVector, nil, 0, nResults, filter.matches)
+}
+
+// validate checks the operators of the filters and the minimum number of
+// matching Should filters.
+func (f BooleanFilter) validate() error {
+	if f.MinimumShouldMatch < 0 || f.MinimumShouldMatch > len(f.Should) {
+		return fmt.Errorf("MinimumShouldMatch must be between 0 and the number of Should filters (%d), got %d", len(f.Should), f.MinimumShouldMatch)
+	}
+	for _, filters := range [][]DocumentFilter{f.Must, f.Should, f.MustNot} {
+		for _, df := range filters {
+			for k := range df.WhereDocument {
+				if !slices.Contains(supportedFilters, k) {
+					return errors.New("unsupported operator")
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// matches checks if the document matches the filter.
+// The filter must already be validated!
+func (f BooleanFilter) matches(doc *Document) bool {
+	for _, df := range f.Must {
+		if !df.matches(doc) {
+			return false
+		}
+	}
+	for _, df := range f.MustNot {
+		if df.matches(doc) {
+			return false
+		}
+	}
+	if f.MinimumShouldMatch == 0 {
+		return true
+	}
+	shouldMatches := 0
+	for _, df := range f.Should {
+		if df.matches(doc) {
+			shouldMatches++
+			if shouldMatches == f.MinimumShouldMatch {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matches checks if the document matches the filter.
+func (df DocumentFilter) matches(doc *Document) bool {
+	return documentMatchesFilters(doc, df.Where, df.WhereDocument)
+}
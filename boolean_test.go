@@ -0,0 +1,153 @@
+package chromem
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestCollection_QueryBoolean(t *testing.T) {
+	ctx := context.Background()
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return []float32{1, 0, 0}, nil
+	}
+
+	db := NewDB()
+	c, err := db.CreateCollection("test", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.Add(ctx, []string{"1", "2", "3", "4"}, [][]float32{{1, 0, 0}, {1, 1, 0}, {1, 1, 1}, {0, 1, 0}}, []map[string]string{
+		{"lang": "en", "category": "news"},
+		{"lang": "de", "category": "news"},
+		{"lang": "en", "category": "blog"},
+		{"lang": "fr", "category": "blog"},
+	}, []string{"hello world", "hallo welt", "hello blog", "bonjour"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	en := DocumentFilter{Where: map[string]string{"lang": "en"}}
+	de := DocumentFilter{Where: map[string]string{"lang": "de"}}
+	fr := DocumentFilter{Where: map[string]string{"lang": "fr"}}
+	news := DocumentFilter{Where: map[string]string{"category": "news"}}
+	hello := DocumentFilter{WhereDocument: map[string]string{"$contains": "hello"}}
+
+	tt := []struct {
+		name   string
+		filter BooleanFilter
+		want   []string
+	}{
+		{
+			name:   "Empty filter",
+			filter: BooleanFilter{},
+			want:   []string{"1", "2", "3", "4"},
+		},
+		{
+			name:   "Must",
+			filter: BooleanFilter{Must: []DocumentFilter{en}},
+			want:   []string{"1", "3"},
+		},
+		{
+			name:   "Multiple Must",
+			filter: BooleanFilter{Must: []DocumentFilter{en, news}},
+			want:   []string{"1"},
+		},
+		{
+			name:   "MustNot",
+			filter: BooleanFilter{MustNot: []DocumentFilter{news}},
+			want:   []string{"3", "4"},
+		},
+		{
+			name:   "MustNot overrides Must",
+			filter: BooleanFilter{Must: []DocumentFilter{en}, MustNot: []DocumentFilter{en}},
+			want:   nil,
+		},
+		{
+			name:   "MustNot overrides Should",
+			filter: BooleanFilter{Should: []DocumentFilter{en, de}, MustNot: []DocumentFilter{news}, MinimumShouldMatch: 1},
+			want:   []string{"3"},
+		},
+		{
+			name:   "Should without Must",
+			filter: BooleanFilter{Should: []DocumentFilter{de, fr}, MinimumShouldMatch: 1},
+			want:   []string{"2", "4"},
+		},
+		{
+			name:   "Should with MinimumShouldMatch 0 has no effect",
+			filter: BooleanFilter{Should: []DocumentFilter{de}},
+			want:   []string{"1", "2", "3", "4"},
+		},
+		{
+			name:   "MinimumShouldMatch 2",
+			filter: BooleanFilter{Should: []DocumentFilter{en, news, hello}, MinimumShouldMatch: 2},
+			want:   []string{"1", "3"},
+		},
+		{
+			name:   "MinimumShouldMatch all",
+			filter: BooleanFilter{Should: []DocumentFilter{en, news, hello}, MinimumShouldMatch: 3},
+			want:   []string{"1"},
+		},
+		{
+			name:   "Must and Should",
+			filter: BooleanFilter{Must: []DocumentFilter{news}, Should: []DocumentFilter{de, fr}, MinimumShouldMatch: 1},
+			want:   []string{"2"},
+		},
+		{
+			name:   "Must, Should and MustNot",
+			filter: BooleanFilter{Must: []DocumentFilter{hello}, Should: []DocumentFilter{en}, MustNot: []DocumentFilter{news}, MinimumShouldMatch: 1},
+			want:   []string{"3"},
+		},
+		{
+			name:   "Filter with Where and WhereDocument",
+			filter: BooleanFilter{Must: []DocumentFilter{{Where: map[string]string{"category": "blog"}, WhereDocument: map[string]string{"$not_contains": "hello"}}}},
+			want:   []string{"4"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := c.QueryBoolean(ctx, "foo", c.Count(), tc.filter)
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+			var got []string
+			for _, r := range res {
+				got = append(got, r.ID)
+			}
+			slices.Sort(got)
+			if !slices.Equal(tc.want, got) {
+				t.Fatal("expected", tc.want, "got", got)
+			}
+		})
+	}
+
+	t.Run("Results sorted by similarity", func(t *testing.T) {
+		res, err := c.QueryBoolean(ctx, "foo", 2, BooleanFilter{MustNot: []DocumentFilter{fr}})
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		if len(res) != 2 || res[0].ID != "1" || res[1].ID != "2" {
+			t.Fatal("expected results 1 and 2, got", res)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		filters := []BooleanFilter{
+			{MinimumShouldMatch: -1},
+			{Should: []DocumentFilter{en}, MinimumShouldMatch: 2},
+			{Must: []DocumentFilter{{WhereDocument: map[string]string{"$invalid": "foo"}}}},
+			{MustNot: []DocumentFilter{{WhereDocument: map[string]string{"$invalid": "foo"}}}},
+		}
+		for _, filter := range filters {
+			_, err := c.QueryBoolean(ctx, "foo", 1, filter)
+			if err == nil {
+				t.Fatal("expected error for filter", filter, "got nil")
+			}
+		}
+		_, err := c.QueryBoolean(ctx, "", 1, BooleanFilter{})
+		if err == nil {
+			t.Fatal("expected error for empty query, got nil")
+		}
+	})
+}
@@ -0,0 +1,193 @@
+// Command chromem-validate checks a persistent chromem-go DB for corrupted data,
+// without modifying the documents. For each collection it checks that:
+//
+//   - the collection metadata and all document files can be read,
+//   - the checksums of the documents match, if they were persisted with
+//     [chromem.WithChecksumVerification],
+//   - all embeddings have the same dimension, which is the dimension of most
+//     documents, as collections don't store it explicitly,
+//   - no embedding contains NaN or Inf values.
+//
+// Collections and document files that can't be loaded are reported
+// individually, and the remaining documents are still checked.
+//
+// It prints a report to stdout and exits with status 1 if any errors are found.
+// Errors that prevent the validation, like invalid arguments or an unreadable
+// DB directory, are written to stderr, with status 2 for invalid arguments and
+// status 1 otherwise.
+//
+// The DB is loaded with [chromem.WithReadOnlyLoad], so no files are modified.
+// This means that pending write-ahead logs aren't replayed, and the mutations in
+// them aren't validated.
+//
+// Usage:
+//
+//	go run ./cmd/chromem-validate --db-path ./chromem-go
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/philippgille/chromem-go"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run validates the DB given by the arguments and writes the report to stdout
+// and other errors and the usage to stderr. It returns the exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("chromem-validate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	dbPath := flags.String("db-path", "", "Path of the persistent DB directory")
+	compress := flags.Bool("compress", false, "Whether the DB files are compressed with gzip")
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+	if *dbPath == "" {
+		fmt.Fprintln(stderr, "error: --db-path is required")
+		flags.Usage()
+		return 2
+	}
+
+	// Invalid paths are usage errors, so they're checked before loading
+	fi, err := os.Stat(*dbPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintln(stderr, "error: DB directory doesn't exist:", *dbPath)
+		} else {
+			fmt.Fprintln(stderr, "error: couldn't get info about DB directory:", err)
+		}
+		return 2
+	} else if !fi.IsDir() {
+		fmt.Fprintln(stderr, "error: DB path is not a directory:", *dbPath)
+		return 2
+	}
+
+	// Reading the metadata and document files and verifying the checksums is
+	// done by loading the DB. Collections and documents that can't be loaded
+	// are skipped and reported.
+	var collectionErrs []chromem.LoadError
+	docErrs := make(map[string][]chromem.LoadError)
+	handleLoadError := func(err chromem.LoadError) {
+		if err.File == "" {
+			collectionErrs = append(collectionErrs, err)
+		} else {
+			docErrs[err.Collection] = append(docErrs[err.Collection], err)
+		}
+	}
+	db, err := chromem.NewPersistentDB(*dbPath, *compress, chromem.WithReadOnlyLoad(), chromem.WithChecksumVerification(), chromem.WithLoadErrorHandler(handleLoadError))
+	if err != nil {
+		fmt.Fprintln(stderr, "error: couldn't load DB:", err)
+		return 1
+	}
+
+	collections := db.ListCollections()
+	names := make([]string, 0, len(collections))
+	for name := range collections {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	errCount := 0
+	for _, name := range names {
+		report, err := validateCollection(collections[name])
+		if err != nil {
+			fmt.Fprintf(stdout, "collection %q: error: %v\n", name, err)
+			errCount++
+			continue
+		}
+		for _, e := range docErrs[name] {
+			report.errs = append(report.errs, fmt.Sprintf("document file %q: %v", e.File, e.Err))
+		}
+		fmt.Fprintf(stdout, "collection %q: %d documents, dimension %d, %d errors\n", name, report.docCount, report.dimension, len(report.errs))
+		for _, e := range report.errs {
+			fmt.Fprintln(stdout, "  -", e)
+		}
+		errCount += len(report.errs)
+	}
+	for _, e := range collectionErrs {
+		fmt.Fprintf(stdout, "collection directory %q: error: %v\n", e.CollectionPath, e.Err)
+		errCount++
+	}
+
+	collectionCount := len(names) + len(collectionErrs)
+	if errCount > 0 {
+		fmt.Fprintf(stdout, "FAILED: %d errors in %d collections\n", errCount, collectionCount)
+		return 1
+	}
+	fmt.Fprintf(stdout, "OK: %d collections\n", collectionCount)
+	return 0
+}
+
+type collectionReport struct {
+	docCount  int
+	dimension int
+	errs      []string
+}
+
+// validateCollection checks the embeddings of all documents of the collection.
+func validateCollection(c *chromem.Collection) (collectionReport, error) {
+	ctx := context.Background()
+
+	var docs []*chromem.Document
+	for doc := range c.DocumentChannel(ctx, 0) {
+		// Cold documents are streamed without their embedding
+		if doc.Tier == chromem.TierCold {
+			loadedDoc, err := c.GetByID(ctx, doc.ID)
+			if err != nil {
+				return collectionReport{}, fmt.Errorf("couldn't load cold document: %w", err)
+			}
+			doc = &loadedDoc
+		}
+		docs = append(docs, doc)
+	}
+	slices.SortFunc(docs, func(a, b *chromem.Document) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	report := collectionReport{
+		docCount:  len(docs),
+		dimension: commonDimension(docs),
+	}
+	for _, doc := range docs {
+		if len(doc.Embedding) != report.dimension {
+			report.errs = append(report.errs, fmt.Sprintf("document %q: embedding has dimension %d, expected %d", doc.ID, len(doc.Embedding), report.dimension))
+		}
+		for i, v := range doc.Embedding {
+			f := float64(v)
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				report.errs = append(report.errs, fmt.Sprintf("document %q: embedding contains invalid value %v at index %d", doc.ID, v, i))
+				break
+			}
+		}
+	}
+	return report, nil
+}
+
+// commonDimension returns the most common embedding dimension of the documents.
+// If there's a tie, the smaller dimension is returned.
+func commonDimension(docs []*chromem.Document) int {
+	counts := make(map[int]int)
+	for _, doc := range docs {
+		counts[len(doc.Embedding)]++
+	}
+	res, resCount := 0, 0
+	for dim, count := range counts {
+		if count > resCount || (count == resCount && dim < res) {
+			res, resCount = dim, count
+		}
+	}
+	return res
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/philippgille/chromem-go"
+)
+
+// createDB creates a persistent DB with checksums and a collection with valid
+// documents. It returns the DB and its path.
+func createDB(t *testing.T) (*chromem.DB, string) {
+	t.Helper()
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "db")
+	db, err := chromem.NewPersistentDB(path, false, chromem.WithChecksumVerification())
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c, err := db.CreateCollection("valid", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.AddDocuments(ctx, []chromem.Document{
+		{ID: "1", Embedding: []float32{1, 0, 0}, Content: "hello world"},
+		{ID: "2", Embedding: []float32{0, 1, 0}, Content: "hallo welt"},
+	}, 1)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	return db, path
+}
+
+func TestRun_Valid(t *testing.T) {
+	_, path := createDB(t)
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	code := run([]string{"--db-path", path}, stdout, stderr)
+	if code != 0 {
+		t.Fatal("expected exit code 0, got", code, "with output", stdout.String())
+	}
+	want := "collection \"valid\": 2 documents, dimension 3, 0 errors\nOK: 1 collections\n"
+	if stdout.String() != want {
+		t.Fatalf("expected output %q, got %q", want, stdout.String())
+	}
+	if stderr.Len() != 0 {
+		t.Fatalf("expected no error output, got %q", stderr.String())
+	}
+}
+
+func TestRun_InvalidEmbeddings(t *testing.T) {
+	ctx := context.Background()
+	db, path := createDB(t)
+	c, err := db.CreateCollection("corrupted", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	nan := float32(math.NaN())
+	err = c.AddDocuments(ctx, []chromem.Document{
+		{ID: "1", Embedding: []float32{1, 0, 0}, Content: "hello world"},
+		{ID: "2", Embedding: []float32{0, 1, 0}, Content: "hallo welt"},
+		{ID: "3", Embedding: []float32{0, nan, 1}, Content: "bonjour le monde"},
+		{ID: "4", Embedding: []float32{0, 1}, Content: "hola mundo"},
+	}, 1)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	code := run([]string{"--db-path", path}, stdout, stderr)
+	if code != 1 {
+		t.Fatal("expected exit code 1, got", code, "with output", stdout.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{
+		"collection \"corrupted\": 4 documents, dimension 3, 2 errors",
+		"document \"3\": embedding contains invalid value NaN",
+		"document \"4\": embedding has dimension 2, expected 3",
+		"collection \"valid\": 2 documents, dimension 3, 0 errors",
+		"FAILED: 2 errors in 2 collections",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected output to contain %q, got %q", want, out)
+		}
+	}
+}
+
+func TestRun_ChecksumMismatch(t *testing.T) {
+	_, path := createDB(t)
+
+	// Modify a document file, but not its checksum
+	var docPath string
+	err := filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(p, ".gob") && filepath.Base(p) != "00000000.gob" {
+			docPath = p
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if docPath == "" {
+		t.Fatal("expected document file, got none")
+	}
+	b, err := os.ReadFile(docPath)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	b[len(b)-1] ^= 0xFF
+	err = os.WriteFile(docPath, b, 0o600)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	code := run([]string{"--db-path", path}, stdout, stderr)
+	if code != 1 {
+		t.Fatal("expected exit code 1, got", code, "with output", stdout.String())
+	}
+	// The corrupted document is reported, and the other one is still checked
+	out := stdout.String()
+	for _, want := range []string{
+		"collection \"valid\": 1 documents, dimension 3, 1 errors",
+		"document file " + strconv.Quote(docPath),
+		"checksum",
+		"FAILED: 1 errors in 1 collections",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected output to contain %q, got %q", want, out)
+		}
+	}
+	if stderr.Len() != 0 {
+		t.Fatalf("expected no error output, got %q", stderr.String())
+	}
+}
+
+func TestRun_UnreadableCollection(t *testing.T) {
+	db, path := createDB(t)
+	c, err := db.CreateCollection("unreadable", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.AddDocument(context.Background(), chromem.Document{ID: "1", Embedding: []float32{1, 0, 0}, Content: "hello world"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	// Corrupt the collection metadata file, which makes the whole collection
+	// unreadable.
+	var collectionPath string
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	for _, entry := range entries {
+		p := filepath.Join(path, entry.Name())
+		b, err := os.ReadFile(filepath.Join(p, "00000000.gob"))
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		if bytes.Contains(b, []byte("unreadable")) {
+			collectionPath = p
+			err = os.WriteFile(filepath.Join(p, "00000000.gob"), []byte("invalid"), 0o600)
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+		}
+	}
+	if collectionPath == "" {
+		t.Fatal("expected collection directory, got none")
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	code := run([]string{"--db-path", path}, stdout, stderr)
+	if code != 1 {
+		t.Fatal("expected exit code 1, got", code, "with output", stdout.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{
+		"collection \"valid\": 2 documents, dimension 3, 0 errors",
+		"collection directory " + strconv.Quote(collectionPath) + ": error: couldn't read collection metadata",
+		"FAILED: 1 errors in 2 collections",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected output to contain %q, got %q", want, out)
+		}
+	}
+	if stderr.Len() != 0 {
+		t.Fatalf("expected no error output, got %q", stderr.String())
+	}
+}
+
+func TestRun_ReadOnly(t *testing.T) {
+	db, path := createDB(t)
+	c := db.GetCollection("valid", nil)
+	err := c.EnableWAL("")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.AddDocument(context.Background(), chromem.Document{ID: "3", Embedding: []float32{0, 0, 1}, Content: "bonjour le monde"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	// Leave a cold document file behind, which loading the DB usually removes,
+	// by restoring the hot document file after moving it to the cold tier.
+	before := readFiles(t, path)
+	err = c.MoveToColdStorage([]string{"2"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	for p, b := range before {
+		if _, err := os.Stat(p); err != nil {
+			err = os.WriteFile(p, []byte(b), 0o600)
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+		}
+	}
+
+	// The WAL isn't replayed and nothing is removed
+	before = readFiles(t, path)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	code := run([]string{"--db-path", path}, stdout, stderr)
+	if code != 0 {
+		t.Fatal("expected exit code 0, got", code, "with output", stdout.String())
+	}
+	after := readFiles(t, path)
+	if !maps.Equal(before, after) {
+		t.Fatal("expected files to be unchanged, got", before, after)
+	}
+}
+
+// readFiles returns the contents of all files in the directory and its
+// subdirectories by their path.
+func readFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	res := make(map[string]string)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		res[p] = string(b)
+		return nil
+	})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	return res
+}
+
+func TestRun_InvalidArgs(t *testing.T) {
+	for _, args := range [][]string{
+		nil,
+		{"--db-path", filepath.Join(t.TempDir(), "missing")},
+		{"--unknown"},
+	} {
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		code := run(args, stdout, stderr)
+		if code != 2 {
+			t.Fatal("expected exit code 2 for args", args, "got", code)
+		}
+		// Errors and usage aren't part of the report
+		if stdout.Len() != 0 {
+			t.Fatalf("expected no report output for args %v, got %q", args, stdout.String())
+		}
+		if stderr.Len() == 0 {
+			t.Fatal("expected error output for args", args)
+		}
+	}
+
+	// The missing directory isn't created
+	path := filepath.Join(t.TempDir(), "missing")
+	_ = run([]string{"--db-path", path}, &bytes.Buffer{}, &bytes.Buffer{})
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("expected DB directory to not exist, got", err)
+	}
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+	"text/template"
+)
+
+// embeddingFuncTemplate is the template for the embedding function file.
+var embeddingFuncTemplate = template.Must(template.New("embed").Parse(`// Code generated by gen-embedding-func from {{.SpecFile}}. DO NOT EDIT.
+
+package chromem
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const baseURL{{.Name}} = {{printf "%q" .BaseURL}}
+
+// NewEmbeddingFunc{{.Name}} returns a function that creates embeddings for a text
+// using the {{.DisplayName}} API.
+{{- if .DocURL}}
+// See {{.DocURL}}
+{{- end}}
+func NewEmbeddingFunc{{.Name}}({{.Params}}) EmbeddingFunc {
+	return newEmbeddingFunc{{.Name}}(baseURL{{.Name}}{{.Args}})
+}
+
+type {{.TypePrefix}}Request struct {
+	Input string ` + "`" + `json:{{printf "%q" .InputField}}` + "`" + `
+{{- if .ModelField}}
+	Model string ` + "`" + `json:{{printf "%q" .ModelField}}` + "`" + `
+{{- end}}
+}
+
+type {{.TypePrefix}}Response {{.ResponseType}}
+
+func newEmbeddingFunc{{.Name}}(baseURL{{.Args}} string) EmbeddingFunc {
+	// We don't set a default timeout here, although it's usually a good idea.
+	// In our case though, the library user can set the timeout on the context,
+	// and it might have to be a long timeout, depending on the text length.
+	client := &http.Client{}
+
+	return func(ctx context.Context, text string) ([]float32, error) {
+		// Prepare the request body.
+		reqBody, err := json.Marshal({{.TypePrefix}}Request{
+			Input: text,
+{{- if .ModelField}}
+			Model: model,
+{{- end}}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("couldn't marshal request body: %w", err)
+		}
+
+		// Create the request. Creating it with context is important for a timeout
+		// to be possible, because the client is configured without a timeout.
+		req, err := http.NewRequestWithContext(ctx, "POST", baseURL+{{printf "%q" .Path}}, bytes.NewBuffer(reqBody))
+		if err != nil {
+			return nil, fmt.Errorf("couldn't create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+{{- if eq .AuthType "bearer"}}
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+{{- else if eq .AuthType "header"}}
+		req.Header.Set({{printf "%q" .AuthHeader}}, apiKey)
+{{- end}}
+
+		// Send the request.
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't send request: %w", err)
+		}
+		defer resp.Body.Close()
+
+		// Check the response status.
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.New("error response from the embedding API: " + resp.Status)
+		}
+
+		// Read and decode the response body.
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read response body: %w", err)
+		}
+		var embeddingResponse {{.TypePrefix}}Response
+		err = json.Unmarshal(body, &embeddingResponse)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't unmarshal response body: %w", err)
+		}
+
+		// Check if the response contains embeddings.
+		if {{.EmptyCheck}} {
+			return nil, errors.New("no embeddings found in the response")
+		}
+
+		v := {{.EmbeddingExpr}}
+{{- if eq .Normalized "true"}}
+		// {{.DisplayName}} embeddings are normalized.
+		return v, nil
+{{- else if eq .Normalized "false"}}
+		// {{.DisplayName}} embeddings are not normalized.
+		return normalizeVector(v), nil
+{{- else}}
+		if !isNormalized(v) {
+			v = normalizeVector(v)
+		}
+		return v, nil
+{{- end}}
+	}
+}
+`))
+
+// embeddingFuncTestTemplate is the template for the test file of the embedding
+// function.
+var embeddingFuncTestTemplate = template.Must(template.New("embed_test").Parse(`// Code generated by gen-embedding-func from {{.SpecFile}}. DO NOT EDIT.
+
+package chromem
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNewEmbeddingFunc{{.Name}}(t *testing.T) {
+{{- if ne .AuthType "none"}}
+	apiKey := "secret"
+{{- end}}
+{{- if .ModelField}}
+	model := "model-small"
+{{- end}}
+	input := "hello world"
+	wantRes := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of ` + "`{-0.1, 0.1, 0.2}`" + `
+
+	// Mock server
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Check URL
+		if r.URL.Path != {{printf "%q" .Path}} {
+			t.Fatal("expected URL", {{printf "%q" .Path}}, "got", r.URL.Path)
+		}
+		// Check method
+		if r.Method != "POST" {
+			t.Fatal("expected method POST, got", r.Method)
+		}
+		// Check headers
+{{- if eq .AuthType "bearer"}}
+		if r.Header.Get("Authorization") != "Bearer "+apiKey {
+			t.Fatal("expected Authorization header", "Bearer "+apiKey, "got", r.Header.Get("Authorization"))
+		}
+{{- else if eq .AuthType "header"}}
+		if r.Header.Get({{printf "%q" .AuthHeader}}) != apiKey {
+			t.Fatal("expected {{.AuthHeader}} header", apiKey, "got", r.Header.Get({{printf "%q" .AuthHeader}}))
+		}
+{{- end}}
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Fatal("expected Content-Type header", "application/json", "got", r.Header.Get("Content-Type"))
+		}
+		// Check body
+		var body map[string]any
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			t.Fatal("unexpected error:", err)
+		}
+		if body[{{printf "%q" .InputField}}] != input {
+			t.Fatal("expected input", input, "got", body[{{printf "%q" .InputField}}])
+		}
+{{- if .ModelField}}
+		if body[{{printf "%q" .ModelField}}] != model {
+			t.Fatal("expected model", model, "got", body[{{printf "%q" .ModelField}}])
+		}
+{{- end}}
+
+		// Write response
+		_, _ = w.Write([]byte(` + "`{{.MockResponse}}`" + `))
+	}))
+	defer ts.Close()
+
+	f := newEmbeddingFunc{{.Name}}(ts.URL{{.Args}})
+	res, err := f(context.Background(), input)
+	if err != nil {
+		t.Fatal("expected nil, got", err)
+	}
+	if !slices.Equal(wantRes, res) {
+		t.Fatal("expected res", wantRes, "got", res)
+	}
+}
+
+func TestNewEmbeddingFunc{{.Name}}_HTTPError(t *testing.T) {
+{{- if ne .AuthType "none"}}
+	apiKey := "secret"
+{{- end}}
+{{- if .ModelField}}
+	model := "model-small"
+{{- end}}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	f := newEmbeddingFunc{{.Name}}(ts.URL{{.Args}})
+	_, err := f(context.Background(), "hello world")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "500") {
+		t.Fatal("expected error to contain the status code, got", err)
+	}
+}
+`))
+
+// templateData is the data for the templates, derived from a spec.
+type templateData struct {
+	spec
+	SpecFile string
+
+	// For the exported constructor
+	Params string
+	// For calling the unexported constructor, starting with a comma
+	Args string
+
+	TypePrefix    string
+	ResponseType  string
+	EmptyCheck    string
+	EmbeddingExpr string
+	MockResponse  string
+}
+
+// generate generates the embedding function file and its test file for the spec.
+//
+//   - specFile: The name of the spec file, which is mentioned in the generated
+//     files.
+func generate(s spec, specFile string) (code, test []byte, err error) {
+	data := templateData{
+		spec:     s,
+		SpecFile: specFile,
+
+		TypePrefix: strings.ToLower(s.Name[:1]) + s.Name[1:],
+	}
+
+	var args []string
+	if s.AuthType != authTypeNone {
+		args = append(args, "apiKey")
+	}
+	if s.ModelField != "" {
+		args = append(args, "model")
+	}
+	if len(args) > 0 {
+		data.Params = strings.Join(args, ", ") + " string"
+		data.Args = ", " + strings.Join(args, ", ")
+	}
+
+	data.ResponseType = responseType(s.EmbeddingPath)
+	data.EmptyCheck, data.EmbeddingExpr = embeddingAccess("embeddingResponse", s.EmbeddingPath)
+	data.MockResponse = mockResponse(s.EmbeddingPath, "[-0.40824828,0.40824828,0.81649655]")
+
+	code, err = execute(embeddingFuncTemplate, data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't generate embedding function: %w", err)
+	}
+	test, err = execute(embeddingFuncTestTemplate, data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't generate embedding function test: %w", err)
+	}
+	return code, test, nil
+}
+
+// execute executes the template and formats the result as Go code.
+func execute(tmpl *template.Template, data templateData) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	err := tmpl.Execute(buf, data)
+	if err != nil {
+		return nil, err
+	}
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("couldn't format generated code: %w", err)
+	}
+	return formatted, nil
+}
+
+// responseType returns the Go type for a JSON response with the embedding at
+// the given path, for example for ["data", "0", "embedding"]:
+//
+//	struct {
+//		Data []struct {
+//			Embedding []float32 `json:"embedding"`
+//		} `json:"data"`
+//	}
+func responseType(path []string) string {
+	if len(path) == 0 {
+		return "[]float32"
+	}
+	if isIndex(path[0]) {
+		return "[]" + responseType(path[1:])
+	}
+	return fmt.Sprintf("struct {\n%s %s `json:%q`\n}", fieldName(path[0]), responseType(path[1:]), path[0])
+}
+
+// embeddingAccess returns the condition for checking that the response contains
+// an embedding, and the expression for accessing it.
+func embeddingAccess(varName string, path []string) (emptyCheck, expr string) {
+	expr = varName
+	var checks []string
+	for _, seg := range path {
+		if idx, ok := parseIndex(seg); ok {
+			if idx == 0 {
+				checks = append(checks, fmt.Sprintf("len(%s) == 0", expr))
+			} else {
+				checks = append(checks, fmt.Sprintf("len(%s) <= %d", expr, idx))
+			}
+			expr += "[" + seg + "]"
+		} else {
+			expr += "." + fieldName(seg)
+		}
+	}
+	checks = append(checks, fmt.Sprintf("len(%s) == 0", expr))
+	return strings.Join(checks, " || "), expr
+}
+
+// mockResponse returns a JSON response with the embedding at the given path.
+// For indexes, the array is filled up with copies of the embedding.
+func mockResponse(path []string, embedding string) string {
+	if len(path) == 0 {
+		return embedding
+	}
+	inner := mockResponse(path[1:], embedding)
+	if isIndex(path[0]) {
+		idx, _ := parseIndex(path[0])
+		elems := make([]string, idx+1)
+		for i := range elems {
+			elems[i] = inner
+		}
+		return "[" + strings.Join(elems, ",") + "]"
+	}
+	return fmt.Sprintf("{%q:%s}", path[0], inner)
+}
+
+// fieldName returns the exported Go field name for a JSON key, for example
+// "Embedding" for "embedding" and "TextEmbedding" for "text_embedding".
+func fieldName(key string) string {
+	var sb strings.Builder
+	for _, part := range strings.FieldsFunc(key, func(r rune) bool {
+		return r == '_' || r == '-'
+	}) {
+		sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return sb.String()
+}
@@ -0,0 +1,72 @@
+// Command gen-embedding-func generates embedding functions for providers with a
+// simple JSON API, based on spec files in YAML format. For each spec file it
+// generates an embed_{file}.go file with the embedding function and an
+// embed_{file}_test.go file with tests against a mock server.
+//
+// See the spec type for the format of the spec files.
+//
+// Usage:
+//
+//	go run ./cmd/gen-embedding-func -specs ./embedspecs -out .
+//
+// It's called by `go generate ./...` in the root of the repository.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	specDir := flag.String("specs", "embedspecs", "Directory with the spec files (*.yaml)")
+	outDir := flag.String("out", ".", "Directory to write the generated files to")
+	flag.Parse()
+
+	err := run(*specDir, *outDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// run generates the files for all spec files in specDir and writes them to
+// outDir.
+func run(specDir, outDir string) error {
+	specFiles, err := filepath.Glob(filepath.Join(specDir, "*.yaml"))
+	if err != nil {
+		return fmt.Errorf("couldn't list spec files: %w", err)
+	}
+	if len(specFiles) == 0 {
+		return fmt.Errorf("no spec files found in %s", specDir)
+	}
+
+	for _, specFile := range specFiles {
+		b, err := os.ReadFile(specFile)
+		if err != nil {
+			return fmt.Errorf("couldn't read spec file: %w", err)
+		}
+		s, err := parseSpec(b)
+		if err != nil {
+			return fmt.Errorf("invalid spec file %s: %w", specFile, err)
+		}
+		code, test, err := generate(s, filepath.Base(specFile))
+		if err != nil {
+			return fmt.Errorf("couldn't generate files for spec file %s: %w", specFile, err)
+		}
+
+		fileName := "embed_" + s.File
+		err = os.WriteFile(filepath.Join(outDir, fileName+".go"), code, 0o644)
+		if err != nil {
+			return fmt.Errorf("couldn't write generated file: %w", err)
+		}
+		err = os.WriteFile(filepath.Join(outDir, fileName+"_test.go"), test, 0o644)
+		if err != nil {
+			return fmt.Errorf("couldn't write generated test file: %w", err)
+		}
+		fmt.Printf("generated %s.go and %s_test.go from %s\n", fileName, fileName, specFile)
+	}
+
+	return nil
+}
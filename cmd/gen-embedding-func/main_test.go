@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "Update the golden files")
+
+func TestGenerate_Golden(t *testing.T) {
+	b, err := os.ReadFile("testdata/reference.yaml")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	s, err := parseSpec(b)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	code, test, err := generate(s, "reference.yaml")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	for goldenFile, got := range map[string][]byte{
+		"testdata/embed_reference.go.golden":      code,
+		"testdata/embed_reference_test.go.golden": test,
+	} {
+		if *update {
+			err := os.WriteFile(goldenFile, got, 0o644)
+			if err != nil {
+				t.Fatal("couldn't update golden file:", err)
+			}
+			continue
+		}
+		want, err := os.ReadFile(goldenFile)
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		if !bytes.Equal(want, got) {
+			t.Fatalf("generated code doesn't match %s, run `go test -update` if the change is expected. Got:\n%s", goldenFile, got)
+		}
+	}
+}
+
+// TestRun_UpToDate checks that the committed generated files match the specs.
+func TestRun_UpToDate(t *testing.T) {
+	outDir := t.TempDir()
+	err := run("../../embedspecs", outDir)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	generatedFiles, err := filepath.Glob(filepath.Join(outDir, "*.go"))
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if len(generatedFiles) == 0 {
+		t.Fatal("expected generated files, got none")
+	}
+	for _, generatedFile := range generatedFiles {
+		got, err := os.ReadFile(generatedFile)
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		want, err := os.ReadFile(filepath.Join("../..", filepath.Base(generatedFile)))
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		if !bytes.Equal(want, got) {
+			t.Fatalf("%s is outdated, run `go generate ./...`", filepath.Base(generatedFile))
+		}
+	}
+}
+
+func TestParseSpec_Errors(t *testing.T) {
+	valid := "name: Test\nbaseURL: http://localhost\nresponse:\n  embeddingPath: embedding\n"
+	if _, err := parseSpec([]byte(valid)); err != nil {
+		t.Fatal("expected valid spec, got", err)
+	}
+
+	tt := []struct {
+		name    string
+		spec    string
+		wantErr string
+	}{
+		{"Unknown key", valid + "foo: bar\n", `unknown key "foo"`},
+		{"Unknown nested key", valid + "auth:\n  foo: bar\n", `unknown key "auth.foo"`},
+		{"Duplicate key", valid + "name: Other\n", "duplicate key"},
+		{"List", valid + "auth:\n  - bearer\n", "lists are not supported"},
+		{"Unexported name", strings.Replace(valid, "Test", "test", 1), "exported Go identifier"},
+		{"Missing base URL", "name: Test\nresponse:\n  embeddingPath: embedding\n", "baseURL is empty"},
+		{"Missing embedding path", "name: Test\nbaseURL: http://localhost\n", "embeddingPath is empty"},
+		{"Index first", strings.Replace(valid, "embedding\n", "0.embedding\n", 1), "must start with a key"},
+		{"Header auth without header", valid + "auth:\n  type: header\n", "auth.header is empty"},
+		{"Unsupported auth", valid + "auth:\n  type: basic\n", "unsupported auth.type"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseSpec([]byte(tc.spec))
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	yaml := `# Comment
+a: b # Comment
+c: "d # not a comment"
+e: 'it''s'
+f: don't # apostrophe
+g:
+  h: i
+  j:
+    k: l
+  m: n
+o: p
+`
+	got, err := parseYAML([]byte(yaml))
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	want := map[string]any{
+		"a": "b",
+		"c": "d # not a comment",
+		"e": "it's",
+		"f": "don't",
+		"g": map[string]any{
+			"h": "i",
+			"j": map[string]any{"k": "l"},
+			"m": "n",
+		},
+		"o": "p",
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
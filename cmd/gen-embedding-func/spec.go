@@ -0,0 +1,320 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// spec describes an embedding provider with a simple JSON API.
+//
+// Example spec file:
+//
+//	name: VoyageAI           # Used in Go identifiers, like NewEmbeddingFuncVoyageAI
+//	displayName: Voyage AI   # Used in doc comments
+//	file: voyageai           # The generated file is embed_voyageai.go
+//	docURL: https://docs.voyageai.com/reference/embeddings-api
+//	baseURL: https://api.voyageai.com/v1
+//	path: /embeddings
+//	auth:
+//	  type: bearer           # bearer, header or none
+//	  header: ""             # Name of the header for type header, like x-api-key
+//	request:
+//	  inputField: input      # JSON field for the text
+//	  modelField: model      # Optional JSON field for the model
+//	response:
+//	  embeddingPath: data.0.embedding  # Dot separated path to the embedding
+//	  normalized: true       # true, false or empty if unknown
+type spec struct {
+	Name        string
+	DisplayName string
+	File        string
+	DocURL      string
+	BaseURL     string
+	Path        string
+
+	AuthType   string
+	AuthHeader string
+
+	InputField string
+	ModelField string
+
+	EmbeddingPath []string
+	Normalized    string
+}
+
+const (
+	authTypeBearer = "bearer"
+	authTypeHeader = "header"
+	authTypeNone   = "none"
+)
+
+var fileNameRegex = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// allowedSpecKeys are the keys that are allowed in a spec file, per section.
+// The empty section is the top level.
+var allowedSpecKeys = map[string][]string{
+	"":         {"name", "displayName", "file", "docURL", "baseURL", "path", "auth", "request", "response"},
+	"auth":     {"type", "header"},
+	"request":  {"inputField", "modelField"},
+	"response": {"embeddingPath", "normalized"},
+}
+
+// parseSpec parses and validates a spec file.
+func parseSpec(b []byte) (spec, error) {
+	m, err := parseYAML(b)
+	if err != nil {
+		return spec{}, fmt.Errorf("couldn't parse YAML: %w", err)
+	}
+
+	// Check for unknown keys, which are likely typos
+	for section, keys := range allowedSpecKeys {
+		sectionMap := m
+		if section != "" {
+			v, ok := m[section]
+			if !ok {
+				continue
+			}
+			sectionMap, ok = v.(map[string]any)
+			if !ok {
+				return spec{}, fmt.Errorf("%q must be a map", section)
+			}
+		}
+		for k, v := range sectionMap {
+			name := k
+			if section != "" {
+				name = section + "." + k
+			}
+			if !slices.Contains(keys, k) {
+				return spec{}, fmt.Errorf("unknown key %q", name)
+			}
+			if _, isSection := allowedSpecKeys[k]; isSection && section == "" {
+				// Checked as section
+				continue
+			}
+			if _, ok := v.(string); !ok {
+				return spec{}, fmt.Errorf("%q must be a string", name)
+			}
+		}
+	}
+	get := func(path string) string {
+		section, key, ok := strings.Cut(path, ".")
+		if !ok {
+			v, _ := m[path].(string)
+			return v
+		}
+		sectionMap, _ := m[section].(map[string]any)
+		v, _ := sectionMap[key].(string)
+		return v
+	}
+
+	s := spec{
+		Name:        get("name"),
+		DisplayName: get("displayName"),
+		File:        get("file"),
+		DocURL:      get("docURL"),
+		BaseURL:     strings.TrimSuffix(get("baseURL"), "/"),
+		Path:        get("path"),
+		AuthType:    get("auth.type"),
+		AuthHeader:  get("auth.header"),
+		InputField:  get("request.inputField"),
+		ModelField:  get("request.modelField"),
+		Normalized:  get("response.normalized"),
+	}
+	if embeddingPath := get("response.embeddingPath"); embeddingPath != "" {
+		s.EmbeddingPath = strings.Split(embeddingPath, ".")
+	}
+
+	// Defaults
+	if s.DisplayName == "" {
+		s.DisplayName = s.Name
+	}
+	if s.File == "" {
+		s.File = strings.ToLower(s.Name)
+	}
+	if s.Path == "" {
+		s.Path = "/embeddings"
+	}
+	if s.AuthType == "" {
+		s.AuthType = authTypeBearer
+	}
+	if s.InputField == "" {
+		s.InputField = "input"
+	}
+
+	err = s.validate()
+	if err != nil {
+		return spec{}, err
+	}
+	return s, nil
+}
+
+func (s spec) validate() error {
+	if !token.IsIdentifier(s.Name) || !token.IsExported(s.Name) {
+		return fmt.Errorf("name must be an exported Go identifier, got %q", s.Name)
+	}
+	if !fileNameRegex.MatchString(s.File) {
+		return fmt.Errorf("file must only contain lowercase letters, digits and underscores, got %q", s.File)
+	}
+	if s.BaseURL == "" {
+		return errors.New("baseURL is empty")
+	}
+	if !strings.HasPrefix(s.Path, "/") {
+		return fmt.Errorf("path must start with a slash, got %q", s.Path)
+	}
+	switch s.AuthType {
+	case authTypeBearer, authTypeNone:
+	case authTypeHeader:
+		if s.AuthHeader == "" {
+			return errors.New("auth.header is empty")
+		}
+	default:
+		return fmt.Errorf("unsupported auth.type %q", s.AuthType)
+	}
+	if s.InputField == s.ModelField {
+		return errors.New("request.inputField and request.modelField must be different")
+	}
+	if len(s.EmbeddingPath) == 0 {
+		return errors.New("response.embeddingPath is empty")
+	}
+	for i, seg := range s.EmbeddingPath {
+		if seg == "" {
+			return fmt.Errorf("response.embeddingPath contains an empty segment")
+		}
+		if isIndex(seg) {
+			if i == 0 {
+				return errors.New("response.embeddingPath must start with a key, not an index")
+			}
+		} else if !token.IsIdentifier(fieldName(seg)) {
+			return fmt.Errorf("response.embeddingPath contains unsupported key %q", seg)
+		}
+	}
+	switch s.Normalized {
+	case "", "true", "false":
+	default:
+		return fmt.Errorf("response.normalized must be true, false or empty, got %q", s.Normalized)
+	}
+	return nil
+}
+
+// isIndex returns true if the path segment is an array index.
+func isIndex(seg string) bool {
+	_, ok := parseIndex(seg)
+	return ok
+}
+
+// parseIndex parses the path segment as array index.
+func parseIndex(seg string) (int, bool) {
+	idx, err := strconv.ParseUint(seg, 10, 16)
+	if err != nil {
+		return 0, false
+	}
+	return int(idx), true
+}
+
+// parseYAML parses the subset of YAML that's used by spec files: maps, nested by
+// indentation with spaces, with string values that are optionally quoted, and
+// comments. Lists, multi-line strings, anchors etc. are not supported.
+// We don't use a full YAML library to keep chromem-go free of third-party
+// dependencies.
+func parseYAML(b []byte) (map[string]any, error) {
+	type frame struct {
+		indent int
+		m      map[string]any
+	}
+	root := map[string]any{}
+	stack := []frame{{indent: -1, m: root}}
+
+	for i, line := range strings.Split(string(b), "\n") {
+		lineNo := i + 1
+		line = strings.TrimRight(stripYAMLComment(line), " \t\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		content := strings.TrimLeft(line, " ")
+		indent := len(line) - len(content)
+		if strings.HasPrefix(content, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", lineNo)
+		}
+		if content == "-" || strings.HasPrefix(content, "- ") {
+			return nil, fmt.Errorf("line %d: lists are not supported", lineNo)
+		}
+		key, value, ok := strings.Cut(content, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key: value", lineNo)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if key == "" {
+			return nil, fmt.Errorf("line %d: key is empty", lineNo)
+		}
+
+		// Go back to the map that this line belongs to
+		for indent <= stack[len(stack)-1].indent {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1].m
+		if _, ok := parent[key]; ok {
+			return nil, fmt.Errorf("line %d: duplicate key %q", lineNo, key)
+		}
+
+		if value == "" {
+			// Start of a nested map
+			m := map[string]any{}
+			parent[key] = m
+			stack = append(stack, frame{indent: indent, m: m})
+			continue
+		}
+		v, err := unquoteYAML(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		parent[key] = v
+	}
+
+	return root, nil
+}
+
+// stripYAMLComment removes a comment from the line. A "#" starts a comment if
+// it's at the start of the line or preceded by whitespace, and not quoted.
+// Quotes are only recognized at the start of a value, so that apostrophes in
+// unquoted values work.
+func stripYAMLComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case (r == '"' || r == '\'') && i > 0 && line[i-1] == ' ':
+			quote = r
+		case r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// unquoteYAML returns the string value, removing quotes if present.
+func unquoteYAML(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		v, err := strconv.Unquote(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid double-quoted string %s", value)
+		}
+		return v, nil
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") {
+			return "", fmt.Errorf("invalid single-quoted string %s", value)
+		}
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'"), nil
+	default:
+		return value, nil
+	}
+}
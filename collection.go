@@ -4,10 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/fs"
 	"maps"
+	"math"
+	"os"
 	"path/filepath"
 	"slices"
 	"sync"
+	"time"
 )
 
 // Collection represents a collection of documents.
@@ -16,18 +20,66 @@ import (
 type Collection struct {
 	Name string
 
-	metadata      map[string]string
-	documents     map[string]*Document
+	metadata  map[string]string
+	documents map[string]*Document
+	// Cold documents, without their embeddings. See [Collection.MoveToColdStorage].
+	cold          map[string]*Document
 	documentsLock sync.RWMutex
 	embed         EmbeddingFunc
 
 	persistDirectory string
 	compress         bool
+	checksum         bool
+	// Number of shard directories, 0 if not sharded. See [Collection.EnableSharding].
+	shards int
+	// Reference vectors for the delta encoding of persisted embeddings. See
+	// [Collection.EnableDeltaEncoding]. The previous one is only set while the
+	// documents are re-encoded.
+	deltaReference     []float32
+	prevDeltaReference []float32
+	// compressMetadata is set via [WithMetadataCompression]
+	compressMetadata bool
+
+	// embeddingPricePerToken is the price in USD per input token of the
+	// embedding API, used for cost estimations in [Collection.QueryDryRun].
+	embeddingPricePerToken float64
+
+	// Optional scoring adjustments
+	temporalDecay *temporalDecay
+
+	// validator is set via [Collection.SetDocumentValidator]
+	validator DocumentValidator
+	// idGenerator is set via [Collection.SetIDGenerator]
+	idGenerator IDGeneratorFunc
+
+	// Write-ahead log, enabled via [Collection.EnableWAL]
+	walPath string
+	walLock sync.Mutex
+
+	// Auto-save, enabled via [Collection.EnableAutoSave]. When deferWrites is
+	// set, added documents are only marked as dirty instead of being written to
+	// disk. It's guarded by documentsLock.
+	deferWrites  bool
+	dirty        map[string]struct{}
+	dirtyLock    sync.Mutex
+	autoSave     *autoSave
+	autoSaveLock sync.Mutex
+
+	// Set when the collection is removed from the DB by [DB.Reset] or
+	// [DB.DeleteCollection]. inFlight tracks the running operations, which are
+	// awaited before the collection's files are removed.
+	closed     bool
+	closedLock sync.RWMutex
+	inFlight   sync.WaitGroup
 
 	// ⚠️ When adding fields here, consider adding them to the persistence struct
 	// versions in [DB.Export] and [DB.Import] as well!
 }
 
+// ErrCollectionClosed is returned when writing to a collection that was removed
+// from its DB by [DB.Reset] or [DB.DeleteCollection].
+var ErrCollectionClosed = errors.New("collection is closed")
+
 // NegativeMode represents the mode to use for the negative text.
 // See QueryOptions for more information.
 type NegativeMode string
@@ -89,9 +141,53 @@ type NegativeQueryOptions struct {
 	FilterThreshold float32
 }
 
+// CollectionOption is an option for a collection, which can be passed when
+// creating it via [DB.CreateCollection] or [DB.GetOrCreateCollection].
+//
+// Options are not persisted, so when loading a persistent DB, the options of
+// the collections are not set anymore.
+type CollectionOption func(*Collection)
+
+// WithTemporalDecay makes newer documents rank higher than older ones when
+// querying the collection, all else being equal. Each document's similarity is
+// multiplied by exp(-ln(2) * age / halfLife), so a document that is halfLife old
+// gets half of its original similarity.
+// Similarities decay towards 0, so negative similarities increase with age. For
+// documents that are dissimilar to the query, older ones rank higher than newer
+// ones.
+// The age is calculated from the document's metadata value for timestampMetaKey,
+// which must be formatted as [time.RFC3339]. Documents without this metadata
+// value, or with a value that can't be parsed, are not decayed.
+// If halfLife is <= 0, no decay is applied.
+func WithTemporalDecay(halfLife time.Duration, timestampMetaKey string) CollectionOption {
+	return func(c *Collection) {
+		if halfLife <= 0 {
+			c.temporalDecay = nil
+			return
+		}
+		c.temporalDecay = &temporalDecay{
+			halfLife:     halfLife,
+			timestampKey: timestampMetaKey,
+		}
+	}
+}
+
+// WithMetadataCompression makes a persistent collection compress long metadata
+// values with gzip before persisting documents. Values shorter than 256 bytes
+// are not compressed, as the overhead would outweigh the savings.
+// Decompression when loading the DB happens transparently, also when the
+// collection is loaded without this option.
+// This is mostly useful when the DB itself is created without compression, but
+// some metadata values are long, like full article abstracts.
+func WithMetadataCompression() CollectionOption {
+	return func(c *Collection) {
+		c.compressMetadata = true
+	}
+}
+
 // We don't export this yet to keep the API surface to the bare minimum.
 // Users create collections via [Client.CreateCollection].
-func newCollection(name string, metadata map[string]string, embed EmbeddingFunc, dbDir string, compress bool) (*Collection, error) {
+func newCollection(name string, metadata map[string]string, embed EmbeddingFunc, dbDir string, compress bool, opts ...CollectionOption) (*Collection, error) {
 	// We copy the metadata to avoid data races in case the caller modifies the
 	// map after creating the collection while we range over it.
 	m := make(map[string]string, len(metadata))
@@ -105,6 +201,11 @@ func newCollection(name string, metadata map[string]string, embed EmbeddingFunc,
 		metadata:  m,
 		documents: make(map[string]*Document),
 		embed:     embed,
+
+		embeddingPricePerToken: DefaultEmbeddingPricePerToken,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
 
 	// Persistence
@@ -118,6 +219,22 @@ func newCollection(name string, metadata map[string]string, embed EmbeddingFunc,
 	return c, nil
 }
 
+// DocumentValidator validates a document before it's added to a collection.
+// It can for example enforce content length limits, required metadata keys or
+// prohibited content patterns. A non-nil error rejects the document.
+type DocumentValidator func(id string, content string, metadata map[string]string) error
+
+// SetDocumentValidator sets the validator that's called for each document that's
+// added to the collection, before its embedding is created. If the validator
+// returns an error, the document is neither embedded nor stored, and the add
+// method returns the error.
+// Pass nil to remove the validator.
+func (c *Collection) SetDocumentValidator(v DocumentValidator) {
+	c.documentsLock.Lock()
+	defer c.documentsLock.Unlock()
+	c.validator = v
+}
+
 // Add embeddings to the datastore.
 //
 //   - ids: The ids of the embeddings you wish to add
@@ -248,12 +365,33 @@ func (c *Collection) AddDocuments(ctx context.Context, documents []Document, con
 // If the document doesn't have an embedding, it will be created using the collection's
 // embedding function.
 func (c *Collection) AddDocument(ctx context.Context, doc Document) error {
+	end, err := c.beginOp(true)
+	if err != nil {
+		return err
+	}
+	defer end()
+
 	if doc.ID == "" {
 		return errors.New("document ID is empty")
 	}
 	if len(doc.Embedding) == 0 && doc.Content == "" {
 		return errors.New("either document embedding or content must be filled")
 	}
+	if doc.Tier == TierCold && c.persistDirectory == "" {
+		return errors.New("cold documents can only be added to persistent collections")
+	}
+
+	// Validate before creating the embedding, to not waste API quota on invalid
+	// documents.
+	c.documentsLock.RLock()
+	validator := c.validator
+	c.documentsLock.RUnlock()
+	if validator != nil {
+		err := validator(doc.ID, doc.Content, doc.Metadata)
+		if err != nil {
+			return fmt.Errorf("document '%s' is invalid: %w", doc.ID, err)
+		}
+	}
 
 	// We copy the metadata to avoid data races in case the caller modifies the
 	// map after creating the document while we range over it.
@@ -262,6 +400,12 @@ func (c *Collection) AddDocument(ctx context.Context, doc Document) error {
 		m[k] = v
 	}
 
+	// Sparse embeddings are normalized as well, so that the sparse similarity
+	// is in the same range as the dense one.
+	if len(doc.SparseEmbedding) != 0 {
+		doc.SparseEmbedding = normalizeSparseVector(doc.SparseEmbedding)
+	}
+
 	// Create embedding if they don't exist, otherwise normalize if necessary
 	if len(doc.Embedding) == 0 {
 		embedding, err := c.embed(ctx, doc.Content)
@@ -275,27 +419,115 @@ func (c *Collection) AddDocument(ctx context.Context, doc Document) error {
 		}
 	}
 
+	// The document is logged and written while holding the lock, so that the
+	// location and encoding of the files can't change in between, see
+	// [DB.MovePersistenceDir], [Collection.EnableSharding] and
+	// [Collection.EnableDeltaEncoding].
 	c.documentsLock.Lock()
-	// We don't defer the unlock because we want to do it earlier.
-	c.documents[doc.ID] = &doc
-	c.documentsLock.Unlock()
+	defer c.documentsLock.Unlock()
 
-	// Persist the document
+	// The WAL entry is appended before the document becomes visible, so that
+	// it's never returned by a query without being logged.
 	if c.persistDirectory != "" {
-		docPath := c.getDocPath(doc.ID)
-		err := persistToFile(docPath, doc, c.compress, "")
+		err := c.appendWAL(walEntry{Op: walOpAdd, Document: &doc})
 		if err != nil {
-			return fmt.Errorf("couldn't persist document to %q: %w", docPath, err)
+			return err
+		}
+	}
+	if doc.Tier == TierCold {
+		c.addColdDocument(&doc)
+		// Cold documents aren't tracked by the auto-save, so they're always
+		// written right away.
+		return c.persistColdDocument(&doc)
+	}
+	c.documents[doc.ID] = &doc
+	// The cold file is only removed after the hot one is written, so that the
+	// document isn't lost if the process stops in between. When loading, the
+	// hot file takes precedence.
+	_, wasCold := c.cold[doc.ID]
+	delete(c.cold, doc.ID)
+
+	// Persist the document, unless it's written by the auto-save
+	if c.deferWrites {
+		c.markDirty(doc.ID)
+	} else if c.persistDirectory != "" {
+		err := c.persistDocument(&doc)
+		if err != nil {
+			return err
+		}
+		if wasCold {
+			err = c.removeColdDocumentFile(doc.ID)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
 
+// AddHybrid adds a document with both a dense and a sparse vector representation
+// to the collection. Such documents can be queried with [Collection.QueryHybrid].
+//
+//   - id: The ID of the document.
+//   - dense: The dense embedding of the document. If nil, it will be created
+//     based on the content using the embeddingFunc set for the Collection.
+//   - sparse: The sparse vector of the document, mapping dimension to value,
+//     like the output of SPLADE or BM25. It's normalized before storing.
+//   - metadata: The metadata to associate with the document. Optional.
+//   - content: The content of the document.
+//
+// This is a Chroma-like method. For a more Go-idiomatic one, see [Collection.AddHybridDocument].
+func (c *Collection) AddHybrid(ctx context.Context, id string, dense []float32, sparse map[uint32]float32, metadata map[string]string, content string) error {
+	return c.AddHybridDocument(ctx, HybridDocument{
+		ID:           id,
+		Metadata:     metadata,
+		Content:      content,
+		DenseVector:  dense,
+		SparseVector: sparse,
+	})
+}
+
+// AddHybridDocument adds a document with both a dense and a sparse vector
+// representation to the collection. If the document doesn't have a dense
+// vector, it will be created using the collection's embedding function.
+func (c *Collection) AddHybridDocument(ctx context.Context, doc HybridDocument) error {
+	return c.AddDocument(ctx, Document{
+		ID:              doc.ID,
+		Metadata:        doc.Metadata,
+		Embedding:       doc.DenseVector,
+		Content:         doc.Content,
+		SparseEmbedding: doc.SparseVector,
+	})
+}
+
+// AddEmbeddingOnly adds a document without content to the collection, for
+// example for an image or audio file whose embedding was created with a
+// multimodal model. The collection's embedding function isn't called. Such
+// documents can be queried like any other, but don't match content filters
+// like "$contains".
+//
+//   - id: The ID of the document.
+//   - embedding: The embedding of the document. Mandatory.
+//   - metadata: The metadata to associate with the document. Optional.
+func (c *Collection) AddEmbeddingOnly(ctx context.Context, id string, embedding []float32, metadata map[string]string) error {
+	if len(embedding) == 0 {
+		return errors.New("embedding is empty")
+	}
+	return c.AddDocument(ctx, Document{
+		ID:        id,
+		Metadata:  metadata,
+		Embedding: embedding,
+	})
+}
+
 // GetByID returns a document by its ID.
 // The returned document is a copy of the original document, so it can be safely
 // modified without affecting the collection.
 func (c *Collection) GetByID(ctx context.Context, id string) (Document, error) {
+	end, _ := c.beginOp(false)
+	defer end()
+
 	if id == "" {
 		return Document{}, errors.New("document ID is empty")
 	}
@@ -305,30 +537,87 @@ func (c *Collection) GetByID(ctx context.Context, id string) (Document, error) {
 
 	doc, ok := c.documents[id]
 	if ok {
-		// Clone the document
-		res := *doc
-		// Above copies the simple fields, but we need to copy the slices and maps
-		res.Metadata = maps.Clone(doc.Metadata)
-		res.Embedding = slices.Clone(doc.Embedding)
-
-		return res, nil
+		return cloneDocument(doc), nil
+	}
+	if _, ok := c.cold[id]; ok {
+		doc, err := c.loadColdDocument(id)
+		if err != nil {
+			return Document{}, err
+		}
+		return *doc, nil
 	}
 
 	return Document{}, fmt.Errorf("document with ID '%v' not found", id)
 }
 
+// DocumentChannel returns a channel that streams all documents of the collection.
+// The documents are a snapshot taken when calling this method: documents added
+// afterwards are not included, and deleted ones are still streamed. The lock is
+// only held while taking the snapshot, so writes to the collection are not
+// blocked while the caller consumes the channel.
+// The documents are copies, so they can be safely modified without affecting
+// the collection. Cold documents are streamed without their embeddings. The order
+// is not specified.
+// The channel is closed when all documents are sent or when the context is
+// canceled.
+//
+//   - bufSize: The buffer size of the channel. Must be >= 0.
+func (c *Collection) DocumentChannel(ctx context.Context, bufSize int) <-chan *Document {
+	if bufSize < 0 {
+		bufSize = 0
+	}
+
+	// Documents are never modified in place, only replaced, so it's enough to
+	// snapshot the pointers and clone the documents while streaming them.
+	c.documentsLock.RLock()
+	snapshot := make([]*Document, 0, len(c.documents)+len(c.cold))
+	for _, doc := range c.documents {
+		snapshot = append(snapshot, doc)
+	}
+	for _, doc := range c.cold {
+		snapshot = append(snapshot, doc)
+	}
+	c.documentsLock.RUnlock()
+
+	docChan := make(chan *Document, bufSize)
+	go func() {
+		defer close(docChan)
+		for _, doc := range snapshot {
+			// Without this check, select might pick the send case even after
+			// the context is canceled, if the receiver is ready as well.
+			if ctx.Err() != nil {
+				return
+			}
+			docCopy := cloneDocument(doc)
+			select {
+			case <-ctx.Done():
+				return
+			case docChan <- &docCopy:
+			}
+		}
+	}()
+
+	return docChan
+}
+
 // Delete removes document(s) from the collection.
 //
 //   - where: Conditional filtering on metadata. Optional.
 //   - whereDocument: Conditional filtering on documents. Optional.
 //   - ids: The ids of the documents to delete. If empty, all documents are deleted.
 func (c *Collection) Delete(_ context.Context, where, whereDocument map[string]string, ids ...string) error {
+	end, err := c.beginOp(true)
+	if err != nil {
+		return err
+	}
+	defer end()
+
 	// must have at least one of where, whereDocument or ids
 	if len(where) == 0 && len(whereDocument) == 0 && len(ids) == 0 {
 		return fmt.Errorf("must have at least one of where, whereDocument or ids")
 	}
 
-	if len(c.documents) == 0 {
+	if len(c.documents) == 0 && len(c.cold) == 0 {
 		return nil
 	}
 
@@ -346,6 +635,7 @@ func (c *Collection) Delete(_ context.Context, where, whereDocument map[string]s
 	if where != nil || whereDocument != nil {
 		// metadata + content filters
 		filteredDocs := filterDocs(c.documents, where, whereDocument)
+		filteredDocs = append(filteredDocs, filterDocs(c.cold, where, whereDocument)...)
 		for _, doc := range filteredDocs {
 			docIDs = append(docIDs, doc.ID)
 		}
@@ -359,14 +649,38 @@ func (c *Collection) Delete(_ context.Context, where, whereDocument map[string]s
 	}
 
 	for _, docID := range docIDs {
+		// Like when adding, the WAL entry is appended before the in-memory
+		// change. Cold documents are logged as well, so that an earlier WAL
+		// entry for them isn't replayed.
+		if c.persistDirectory != "" {
+			err := c.appendWAL(walEntry{Op: walOpDelete, ID: docID})
+			if err != nil {
+				return err
+			}
+		}
+		if _, ok := c.cold[docID]; ok {
+			err := c.removeColdDocument(docID)
+			if err != nil {
+				return err
+			}
+			continue
+		}
 		delete(c.documents, docID)
+		c.unmarkDirty(docID)
 
 		// Remove the document from disk
 		if c.persistDirectory != "" {
-			docPath := c.getDocPath(docID)
-			err := removeFile(docPath)
+			err := c.removeDocumentFile(docID)
 			if err != nil {
-				return fmt.Errorf("couldn't remove document at %q: %w", docPath, err)
+				return err
+			}
+			// A cold file remains if the document was moved back to the hot
+			// tier by a deferred write that wasn't flushed yet.
+			if c.cold != nil {
+				err = c.removeColdDocumentFile(docID)
+				if err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -378,7 +692,7 @@ func (c *Collection) Delete(_ context.Context, where, whereDocument map[string]s
 func (c *Collection) Count() int {
 	c.documentsLock.RLock()
 	defer c.documentsLock.RUnlock()
-	return len(c.documents)
+	return len(c.documents) + len(c.cold)
 }
 
 // Result represents a single result from a query.
@@ -388,12 +702,40 @@ type Result struct {
 	Embedding []float32
 	Content   string
 
+	// The length of the content in bytes, and the number of metadata entries.
+	// They allow to sort, filter or display results based on the size of the
+	// document without processing the content or metadata.
+	ContentLen    int
+	MetadataCount int
+
 	// The cosine similarity between the query and the document.
 	// The higher the value, the more similar the document is to the query.
 	// The value is in the range [-1, 1].
+	// If the collection was created with [WithTemporalDecay], this is the
+	// decayed similarity. For results of [Collection.QuerySoftmax], this is the
+	// probability in the range [0, 1] instead.
 	Similarity float32
 }
 
+// SortResults returns a copy of the results, sorted by the given less function.
+// It can be used to post-process query results, for example to sort the top
+// results by recency or by a popularity score stored in the metadata.
+// The sort is stable, so results that are equal according to less keep their
+// order by similarity.
+func SortResults(results []Result, less func(a, b Result) bool) []Result {
+	res := slices.Clone(results)
+	slices.SortStableFunc(res, func(a, b Result) int {
+		if less(a, b) {
+			return -1
+		}
+		if less(b, a) {
+			return 1
+		}
+		return 0
+	})
+	return res
+}
+
 // Query performs an exhaustive nearest neighbor search on the collection.
 //
 //   - queryText: The text to search for. Its embedding will be created using the
@@ -415,6 +757,194 @@ func (c *Collection) Query(ctx context.Context, queryText string, nResults int,
 	return c.QueryEmbedding(ctx, queryVector, nResults, where, whereDocument)
 }
 
+// QueryAll performs an exhaustive nearest neighbor search on the collection and
+// returns *all* documents that match the filters with their similarity, sorted
+// by similarity (descending). Unlike [Collection.Query] there's no limit on the
+// number of results. This is meant for development and debugging, for example
+// to understand the distribution of similarities.
+//
+//   - queryText: The text to search for. Its embedding will be created using the
+//     collection's embedding function.
+//   - where: Conditional filtering on metadata. Optional.
+//   - whereDocument: Conditional filtering on documents. Optional.
+func (c *Collection) QueryAll(ctx context.Context, queryText string, where, whereDocument map[string]string) ([]Result, error) {
+	end, _ := c.beginOp(false)
+	defer end()
+
+	if queryText == "" {
+		return nil, errors.New("queryText is empty")
+	}
+
+	// Validate whereDocument operators
+	for k := range whereDocument {
+		if !slices.Contains(supportedFilters, k) {
+			return nil, errors.New("unsupported operator")
+		}
+	}
+
+	queryVector, err := c.embed(ctx, queryText)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create embedding of query: %w", err)
+	}
+	if !isNormalized(queryVector) {
+		queryVector = normalizeVector(queryVector)
+	}
+
+	c.documentsLock.RLock()
+	defer c.documentsLock.RUnlock()
+
+	filteredDocs := filterDocs(c.documents, where, whereDocument)
+	coldDocs, err := c.coldCandidates(where, whereDocument)
+	if err != nil {
+		return nil, err
+	}
+	filteredDocs = append(filteredDocs, coldDocs...)
+	if len(filteredDocs) == 0 {
+		return nil, nil
+	}
+
+	docSims, err := getMostSimilarDocs(ctx, queryVector, nil, 0, filteredDocs, len(filteredDocs), c.scoreAdjustFunc())
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get similarities of docs: %w", err)
+	}
+
+	return c.resultsFromDocSims(docSims, coldDocs), nil
+}
+
+// QueryThenSort is like [Collection.Query], but sorts the results with the
+// given less function afterwards. See [SortResults].
+func (c *Collection) QueryThenSort(ctx context.Context, queryText string, nResults int, where, whereDocument map[string]string, less func(a, b Result) bool) ([]Result, error) {
+	res, err := c.Query(ctx, queryText, nResults, where, whereDocument)
+	if err != nil {
+		return nil, err
+	}
+	return SortResults(res, less), nil
+}
+
+// QuerySoftmax is like [Collection.Query], but converts the similarities of the
+// results to probabilities with the softmax function, so that they are in the
+// range [0, 1] and sum up to 1. The Similarity field of each result is the
+// probability then. The order of the results doesn't change.
+//
+//   - temperature: Must be > 0. The similarities are divided by it before
+//     applying softmax, so a lower temperature puts more probability mass on
+//     the top results, and a higher one distributes it more evenly.
+func (c *Collection) QuerySoftmax(ctx context.Context, queryText string, nResults int, temperature float32, where, whereDocument map[string]string) ([]Result, error) {
+	if temperature <= 0 {
+		return nil, errors.New("temperature must be > 0")
+	}
+	res, err := c.Query(ctx, queryText, nResults, where, whereDocument)
+	if err != nil {
+		return nil, err
+	}
+	softmaxResults(res, temperature)
+	return res, nil
+}
+
+// softmaxResults replaces the similarities of the results with the softmax
+// probabilities, in place.
+func softmaxResults(results []Result, temperature float32) {
+	if len(results) == 0 {
+		return
+	}
+	// Subtracting the maximum doesn't change the result, but prevents exp
+	// from overflowing for low temperatures.
+	maxSim := results[0].Similarity
+	for _, r := range results[1:] {
+		maxSim = max(maxSim, r.Similarity)
+	}
+	exps := make([]float64, len(results))
+	var sum float64
+	for i, r := range results {
+		exps[i] = math.Exp(float64(r.Similarity-maxSim) / float64(temperature))
+		sum += exps[i]
+	}
+	for i := range results {
+		results[i].Similarity = float32(exps[i] / sum)
+	}
+}
+
+// QueryCostEstimate is the estimated cost of a query, as returned by
+// [Collection.QueryDryRun].
+type QueryCostEstimate struct {
+	// The number of calls to the embedding API that the query requires.
+	EmbeddingAPICallsRequired int
+
+	// The estimated number of input tokens for the embedding API. This is an
+	// approximation based on the text length, not an exact token count.
+	EstimatedInputTokens int
+
+	// The estimated cost of the embedding API calls in USD, based on the
+	// collection's price per token. See [Collection.SetEmbeddingPricePerToken].
+	EstimatedCostUSD float64
+
+	// The number of documents whose similarity to the query has to be calculated.
+	DocumentsToScan int
+}
+
+// QueryDryRun estimates the cost of running [Collection.Query] with the given
+// parameters, without calling the embedding API or calculating any similarities.
+// Use [Collection.QueryWithOptionsDryRun] for queries with filters.
+//
+//   - queryText: The text to search for.
+//   - nResults: The maximum number of results to return. Documents in cold
+//     storage are only counted when there are fewer hot documents than this,
+//     as [Collection.Query] only scans them in that case.
+func (c *Collection) QueryDryRun(ctx context.Context, queryText string, nResults int) QueryCostEstimate {
+	return c.QueryWithOptionsDryRun(ctx, QueryOptions{
+		QueryText: queryText,
+		NResults:  nResults,
+	})
+}
+
+// QueryWithOptionsDryRun estimates the cost of running
+// [Collection.QueryWithOptions] with the given options, without calling the
+// embedding API or calculating any similarities. Only the documents that match
+// the Where and WhereDocument filters are counted as documents to scan, and
+// texts whose embedding is passed in the options don't require API calls.
+//
+//   - options: The options for the query. See [QueryOptions] for more information.
+func (c *Collection) QueryWithOptionsDryRun(_ context.Context, options QueryOptions) QueryCostEstimate {
+	var texts []string
+	if len(options.QueryEmbedding) == 0 {
+		texts = append(texts, options.QueryText)
+	}
+	if len(options.Negative.Embedding) == 0 && options.Negative.Text != "" {
+		texts = append(texts, options.Negative.Text)
+	}
+	tokens := 0
+	for _, text := range texts {
+		tokens += estimateTokens(text)
+	}
+
+	c.documentsLock.RLock()
+	defer c.documentsLock.RUnlock()
+
+	// We do an exhaustive nearest neighbor search, so all hot documents that
+	// match the filters are scanned. Cold documents are only loaded if there
+	// aren't enough hot ones, and are filtered the same way.
+	docsToScan := len(filterDocs(c.documents, options.Where, options.WhereDocument))
+	if options.NResults > docsToScan {
+		docsToScan += len(filterDocs(c.cold, options.Where, options.WhereDocument))
+	}
+
+	return QueryCostEstimate{
+		EmbeddingAPICallsRequired: len(texts),
+		EstimatedInputTokens:      tokens,
+		EstimatedCostUSD:          float64(tokens) * c.embeddingPricePerToken,
+		DocumentsToScan:           docsToScan,
+	}
+}
+
+// SetEmbeddingPricePerToken sets the price in USD per input token of the
+// embedding API that is used for cost estimations in [Collection.QueryDryRun].
+// It defaults to [DefaultEmbeddingPricePerToken].
+func (c *Collection) SetEmbeddingPricePerToken(price float64) {
+	c.documentsLock.Lock()
+	defer c.documentsLock.Unlock()
+	c.embeddingPricePerToken = price
+}
+
 // QueryWithOptions performs an exhaustive nearest neighbor search on the collection.
 //
 //   - options: The options for the query. See [QueryOptions] for more information.
@@ -481,6 +1011,26 @@ func (c *Collection) QueryEmbedding(ctx context.Context, queryEmbedding []float3
 
 // queryEmbedding performs an exhaustive nearest neighbor search on the collection.
 func (c *Collection) queryEmbedding(ctx context.Context, queryEmbedding, negativeEmbeddings []float32, negativeFilterThreshold float32, nResults int, where, whereDocument map[string]string) ([]Result, error) {
+	// Validate whereDocument operators
+	for k := range whereDocument {
+		if !slices.Contains(supportedFilters, k) {
+			return nil, errors.New("unsupported operator")
+		}
+	}
+
+	match := func(doc *Document) bool {
+		return documentMatchesFilters(doc, where, whereDocument)
+	}
+	return c.queryEmbeddingFunc(ctx, queryEmbedding, negativeEmbeddings, negativeFilterThreshold, nResults, match)
+}
+
+// queryEmbeddingFunc performs an exhaustive nearest neighbor search on the
+// documents of the collection for which match returns true. match is called
+// concurrently.
+func (c *Collection) queryEmbeddingFunc(ctx context.Context, queryEmbedding, negativeEmbeddings []float32, negativeFilterThreshold float32, nResults int, match func(*Document) bool) ([]Result, error) {
+	end, _ := c.beginOp(false)
+	defer end()
+
 	if len(queryEmbedding) == 0 {
 		return nil, errors.New("queryEmbedding is empty")
 	}
@@ -489,24 +1039,29 @@ func (c *Collection) queryEmbedding(ctx context.Context, queryEmbedding, negativ
 	}
 	c.documentsLock.RLock()
 	defer c.documentsLock.RUnlock()
-	if nResults > len(c.documents) {
+	if nResults > len(c.documents)+len(c.cold) {
 		return nil, errors.New("nResults must be <= the number of documents in the collection")
 	}
 
-	if len(c.documents) == 0 {
+	if len(c.documents) == 0 && len(c.cold) == 0 {
 		return nil, nil
 	}
 
-	// Validate whereDocument operators
-	for k := range whereDocument {
-		if !slices.Contains(supportedFilters, k) {
-			return nil, errors.New("unsupported operator")
+	// Filter docs by metadata and content
+	filteredDocs := filterDocsFunc(c.documents, match)
+
+	// Only if there aren't enough hot and warm documents, we consider the cold
+	// ones, whose embeddings have to be read from disk.
+	var coldDocs []*Document
+	if len(filteredDocs) < nResults {
+		var err error
+		coldDocs, err = c.coldCandidatesFunc(match)
+		if err != nil {
+			return nil, err
 		}
+		filteredDocs = append(filteredDocs, coldDocs...)
 	}
 
-	// Filter docs by metadata and content
-	filteredDocs := filterDocs(c.documents, where, whereDocument)
-
 	// No need to continue if the filters got rid of all documents
 	if len(filteredDocs) == 0 {
 		return nil, nil
@@ -526,29 +1081,164 @@ func (c *Collection) queryEmbedding(ctx context.Context, queryEmbedding, negativ
 	}
 
 	// For the remaining documents, get the most similar docs.
-	nMaxDocs, err := getMostSimilarDocs(ctx, queryEmbedding, negativeEmbeddings, negativeFilterThreshold, filteredDocs, resLen)
+	nMaxDocs, err := getMostSimilarDocs(ctx, queryEmbedding, negativeEmbeddings, negativeFilterThreshold, filteredDocs, resLen, c.scoreAdjustFunc())
 	if err != nil {
 		return nil, fmt.Errorf("couldn't get most similar docs: %w", err)
 	}
 
-	res := make([]Result, 0, len(nMaxDocs))
-	for i := 0; i < len(nMaxDocs); i++ {
+	return c.resultsFromDocSims(nMaxDocs, coldDocs), nil
+}
+
+// QueryHybrid performs an exhaustive nearest neighbor search on the collection,
+// combining the similarities of the dense and sparse vectors linearly:
+// alpha * denseSimilarity + (1-alpha) * sparseSimilarity.
+// Documents without a sparse vector have a sparse similarity of 0.
+//
+//   - denseQuery: The dense embedding of the query. It must be created with the
+//     same embedding model as the document embeddings in the collection.
+//     Can be empty if alpha is 0.
+//   - sparseQuery: The sparse vector of the query, mapping dimension to value.
+//     Can be empty if alpha is 1.
+//   - alpha: The weight of the dense similarity, in the range [0, 1]. 1 means
+//     pure dense search, 0 means pure sparse search.
+//   - nResults: The maximum number of results to return. Must be > 0.
+//     There can be fewer results if a filter is applied.
+//   - where: Conditional filtering on metadata. Optional.
+func (c *Collection) QueryHybrid(ctx context.Context, denseQuery []float32, sparseQuery map[uint32]float32, alpha float32, nResults int, where map[string]string) ([]Result, error) {
+	end, _ := c.beginOp(false)
+	defer end()
+
+	if alpha < 0 || alpha > 1 {
+		return nil, errors.New("alpha must be in the range [0, 1]")
+	}
+	if alpha > 0 && len(denseQuery) == 0 {
+		return nil, errors.New("denseQuery is empty")
+	}
+	if alpha < 1 && len(sparseQuery) == 0 {
+		return nil, errors.New("sparseQuery is empty")
+	}
+	if nResults <= 0 {
+		return nil, errors.New("nResults must be > 0")
+	}
+
+	// Normalize queries, as all document vectors were already normalized when
+	// added to the collection.
+	if alpha > 0 && !isNormalized(denseQuery) {
+		denseQuery = normalizeVector(denseQuery)
+	}
+	if alpha < 1 {
+		sparseQuery = normalizeSparseVector(sparseQuery)
+	}
+
+	c.documentsLock.RLock()
+	defer c.documentsLock.RUnlock()
+	if nResults > len(c.documents)+len(c.cold) {
+		return nil, errors.New("nResults must be <= the number of documents in the collection")
+	}
+
+	filteredDocs := filterDocs(c.documents, where, nil)
+	var coldDocs []*Document
+	if len(filteredDocs) < nResults {
+		var err error
+		coldDocs, err = c.coldCandidates(where, nil)
+		if err != nil {
+			return nil, err
+		}
+		filteredDocs = append(filteredDocs, coldDocs...)
+	}
+	if len(filteredDocs) == 0 {
+		return nil, nil
+	}
+
+	adjust := c.scoreAdjustFunc()
+	nMaxDocs := newMaxDocSims(min(nResults, len(filteredDocs)))
+	for _, doc := range filteredDocs {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		var sim float32
+		if alpha > 0 {
+			denseSim, err := dotProduct(denseQuery, doc.Embedding)
+			if err != nil {
+				return nil, fmt.Errorf("couldn't calculate similarity for document '%s': %w", doc.ID, err)
+			}
+			sim += alpha * denseSim
+		}
+		if alpha < 1 {
+			sim += (1 - alpha) * sparseDotProduct(sparseQuery, doc.SparseEmbedding)
+		}
+		if adjust != nil {
+			sim = adjust(doc, sim)
+		}
+
+		nMaxDocs.add(docSim{docID: doc.ID, similarity: sim})
+	}
+
+	return c.resultsFromDocSims(nMaxDocs.values(), coldDocs), nil
+}
+
+// resultsFromDocSims converts docSims to results. The cold documents that were
+// loaded from disk for the query must be passed, so that their embeddings are
+// included in the results.
+// The caller must hold the documents lock.
+func (c *Collection) resultsFromDocSims(docSims []docSim, coldDocs []*Document) []Result {
+	var loadedColdDocs map[string]*Document
+	if len(coldDocs) != 0 {
+		loadedColdDocs = make(map[string]*Document, len(coldDocs))
+		for _, doc := range coldDocs {
+			loadedColdDocs[doc.ID] = doc
+		}
+	}
+
+	res := make([]Result, 0, len(docSims))
+	for _, ds := range docSims {
+		doc, ok := c.documents[ds.docID]
+		if !ok {
+			doc = loadedColdDocs[ds.docID]
+		}
 		res = append(res, Result{
-			ID:         nMaxDocs[i].docID,
-			Metadata:   c.documents[nMaxDocs[i].docID].Metadata,
-			Embedding:  c.documents[nMaxDocs[i].docID].Embedding,
-			Content:    c.documents[nMaxDocs[i].docID].Content,
-			Similarity: nMaxDocs[i].similarity,
+			ID:            ds.docID,
+			Metadata:      doc.Metadata,
+			Embedding:     doc.Embedding,
+			Content:       doc.Content,
+			ContentLen:    len(doc.Content),
+			MetadataCount: len(doc.Metadata),
+			Similarity:    ds.similarity,
 		})
 	}
+	return res
+}
 
-	return res, nil
+// scoreAdjustFunc returns a function that adjusts the similarity of a document
+// to the query based on the collection's options, or nil if no adjustment is
+// configured.
+func (c *Collection) scoreAdjustFunc() func(doc *Document, sim float32) float32 {
+	if c.temporalDecay == nil {
+		return nil
+	}
+	return c.temporalDecay.adjustFunc(time.Now())
+}
+
+// cloneDocument returns a deep copy of the document.
+func cloneDocument(doc *Document) Document {
+	res := *doc
+	// Above copies the simple fields, but we need to copy the slices and maps
+	res.Metadata = maps.Clone(doc.Metadata)
+	res.Embedding = slices.Clone(doc.Embedding)
+	res.SparseEmbedding = maps.Clone(doc.SparseEmbedding)
+	return res
 }
 
 // getDocPath generates the path to the document file.
+// The caller must hold the documents lock.
 func (c *Collection) getDocPath(docID string) string {
 	safeID := hash2hex(docID)
-	docPath := filepath.Join(c.persistDirectory, safeID)
+	dir := c.persistDirectory
+	if c.shards > 0 {
+		dir = c.shardDir(shardIndex(docID, c.shards))
+	}
+	docPath := filepath.Join(dir, safeID)
 	docPath += ".gob"
 	if c.compress {
 		docPath += ".gz"
@@ -556,6 +1246,128 @@ func (c *Collection) getDocPath(docID string) string {
 	return docPath
 }
 
+// persistDocument persists the document to disk. If checksum verification is
+// enabled, it also writes the checksum sidecar file. Otherwise it removes a
+// sidecar file from an earlier write with checksums, which wouldn't match
+// anymore.
+// The caller must hold the documents lock.
+func (c *Collection) persistDocument(doc *Document) error {
+	return c.persistDocumentToPath(c.getDocPath(doc.ID), doc)
+}
+
+// persistDocumentToPath is like [Collection.persistDocument], but writes to the
+// given path, for example the one of a cold document.
+func (c *Collection) persistDocumentToPath(docPath string, doc *Document) error {
+	obj, err := c.toPersisted(doc)
+	if err != nil {
+		return err
+	}
+	if c.checksum {
+		err = persistToFileWithChecksum(docPath, obj, c.compress)
+	} else {
+		// Removed before writing, so that a stale sidecar file is never left
+		// next to the new document file.
+		err = removeFile(checksumPath(docPath))
+		if err != nil {
+			return fmt.Errorf("couldn't remove checksum of document at %q: %w", docPath, err)
+		}
+		err = persistToFile(docPath, obj, c.compress, "")
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't persist document to %q: %w", docPath, err)
+	}
+	return nil
+}
+
+// removeDocumentFile removes the document and its checksum sidecar file (if
+// any) from disk.
+func (c *Collection) removeDocumentFile(docID string) error {
+	return removeDocumentFileAtPath(c.getDocPath(docID))
+}
+
+// readDocumentFile reads the document from the given path, for example the one
+// of a cold document. If checksum verification is enabled, the checksum is
+// verified first.
+func (c *Collection) readDocumentFile(docPath string) (*Document, error) {
+	if c.checksum {
+		err := verifyChecksum(docPath)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't verify document: %w", err)
+		}
+	}
+	pd := &persistedDocument{}
+	err := readFromFile(docPath, pd, "")
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read document from %q: %w", docPath, err)
+	}
+	doc, err := c.toDocument(pd)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't decode document from %q: %w", docPath, err)
+	}
+	return doc, nil
+}
+
+// removeDocumentFileAtPath removes the document file at the given path and its
+// checksum sidecar file (if any).
+func removeDocumentFileAtPath(docPath string) error {
+	err := removeFile(docPath)
+	if err != nil {
+		return fmt.Errorf("couldn't remove document at %q: %w", docPath, err)
+	}
+	err = removeFile(checksumPath(docPath))
+	if err != nil {
+		return fmt.Errorf("couldn't remove checksum of document at %q: %w", docPath, err)
+	}
+	return nil
+}
+
+// rename renames the persistent collection, including its directory and its
+// write-ahead log if it's in the default location next to the directory. The
+// directory is moved to dbDir, which is the DB or namespace directory.
+func (c *Collection) rename(newName, dbDir string) error {
+	c.documentsLock.Lock()
+	defer c.documentsLock.Unlock()
+	c.walLock.Lock()
+	defer c.walLock.Unlock()
+
+	oldDir := c.persistDirectory
+	newDir := filepath.Join(dbDir, hash2hex(newName))
+	err := os.Rename(oldDir, newDir)
+	if err != nil {
+		return fmt.Errorf("couldn't rename collection directory: %w", err)
+	}
+	if c.walPath == oldDir+walFileExt {
+		newWALPath := newDir + walFileExt
+		err = os.Rename(c.walPath, newWALPath)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("couldn't rename WAL file: %w", err)
+		}
+		c.walPath = newWALPath
+	}
+	c.persistDirectory = newDir
+	c.Name = newName
+
+	// The name is stored in the metadata file
+	err = c.persistMetadata()
+	if err != nil {
+		return fmt.Errorf("couldn't persist collection metadata: %w", err)
+	}
+	return nil
+}
+
+// persistedCollectionMetadata is the structure of the collection metadata file.
+type persistedCollectionMetadata struct {
+	Name     string
+	Metadata map[string]string
+	// Empty if the write-ahead log isn't enabled
+	WALPath string
+	// Empty if delta encoding isn't enabled
+	DeltaReference     []float32
+	PrevDeltaReference []float32
+	// 0 if sharding isn't enabled
+	Shards int
+}
+
 // persistMetadata persists the collection metadata to disk
 func (c *Collection) persistMetadata() error {
 	// Persist name and metadata
@@ -564,12 +1376,14 @@ func (c *Collection) persistMetadata() error {
 	if c.compress {
 		metadataPath += ".gz"
 	}
-	pc := struct {
-		Name     string
-		Metadata map[string]string
-	}{
+	pc := persistedCollectionMetadata{
 		Name:     c.Name,
 		Metadata: c.metadata,
+		WALPath:  c.walPath,
+
+		DeltaReference:     c.deltaReference,
+		PrevDeltaReference: c.prevDeltaReference,
+		Shards:             c.shards,
 	}
 	err := persistToFile(metadataPath, pc, c.compress, "")
 	if err != nil {
@@ -578,3 +1392,32 @@ func (c *Collection) persistMetadata() error {
 
 	return nil
 }
+
+// beginOp registers an operation on the collection, so that [DB.Reset] and
+// [DB.DeleteCollection] wait for it to finish before removing the collection's
+// files. The returned function must be called when the operation is done.
+// Write operations are rejected with [ErrCollectionClosed] when the collection
+// is closed. Read operations still work on the in-memory data then, so they
+// aren't tracked anymore.
+func (c *Collection) beginOp(write bool) (end func(), err error) {
+	c.closedLock.RLock()
+	defer c.closedLock.RUnlock()
+	if c.closed {
+		if write {
+			return nil, ErrCollectionClosed
+		}
+		return func() {}, nil
+	}
+	c.inFlight.Add(1)
+	return c.inFlight.Done, nil
+}
+
+// close makes the collection reject further writes and waits for the running
+// operations to finish.
+func (c *Collection) close() {
+	c.closedLock.Lock()
+	c.closed = true
+	c.closedLock.Unlock()
+
+	c.inFlight.Wait()
+}
@@ -3,11 +3,14 @@ package chromem
 import (
 	"context"
 	"errors"
+	"math"
 	"math/rand"
 	"os"
 	"slices"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestCollection_Add(t *testing.T) {
@@ -158,6 +161,65 @@ func TestCollection_Add_Error(t *testing.T) {
 	}
 }
 
+func TestCollection_SetDocumentValidator(t *testing.T) {
+	ctx := context.Background()
+	vectors := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+	embedCalls := 0
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		embedCalls++
+		return vectors, nil
+	}
+
+	db := NewDB()
+	c, err := db.CreateCollection("test", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	errEmptyContent := errors.New("content is empty")
+	c.SetDocumentValidator(func(_ string, content string, _ map[string]string) error {
+		if content == "" {
+			return errEmptyContent
+		}
+		return nil
+	})
+
+	// Rejected, with an embedding so it passes the basic checks
+	err = c.Add(ctx, []string{"1"}, [][]float32{vectors}, nil, []string{""})
+	if !errors.Is(err, errEmptyContent) {
+		t.Fatal("expected validator error, got", err)
+	}
+	if c.Count() != 0 {
+		t.Fatal("expected no documents, got", c.Count())
+	}
+
+	// Accepted
+	err = c.Add(ctx, []string{"1"}, nil, nil, []string{"hello world"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if c.Count() != 1 {
+		t.Fatal("expected 1 document, got", c.Count())
+	}
+	if embedCalls != 1 {
+		t.Fatal("expected 1 call of the embedding func, got", embedCalls)
+	}
+
+	// Rejected before the embedding is created
+	c.SetDocumentValidator(func(id string, _ string, metadata map[string]string) error {
+		if metadata["source"] == "" {
+			return errors.New("missing source in document " + id)
+		}
+		return nil
+	})
+	err = c.Add(ctx, []string{"2"}, nil, nil, []string{"hallo welt"})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if embedCalls != 1 {
+		t.Fatal("expected embedding func to not be called again, got", embedCalls)
+	}
+}
+
 func TestCollection_AddConcurrently(t *testing.T) {
 	ctx := context.Background()
 	name := "test"
@@ -310,6 +372,76 @@ func TestCollection_AddConcurrently_Error(t *testing.T) {
 	}
 }
 
+func TestCollection_AddEmbeddingOnly(t *testing.T) {
+	ctx := context.Background()
+	var embedCalls int
+	embeddingFunc := func(_ context.Context, text string) ([]float32, error) {
+		embedCalls++
+		if text == "" {
+			t.Fatal("expected embedding function not to be called with empty text")
+		}
+		return []float32{1, 0, 0}, nil
+	}
+
+	db := NewDB()
+	c, err := db.CreateCollection("test", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	// Mix of text documents and embedding-only documents
+	err = c.AddDocument(ctx, Document{ID: "text", Content: "hello world"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.AddEmbeddingOnly(ctx, "image", []float32{0.9, 0.1, 0}, map[string]string{"type": "image"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.AddEmbeddingOnly(ctx, "audio", []float32{0, 0, 1}, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if embedCalls != 1 {
+		t.Fatal("expected 1 embedding call, got", embedCalls)
+	}
+
+	doc, err := c.GetByID(ctx, "image")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if doc.Content != "" || doc.Metadata["type"] != "image" || !isNormalized(doc.Embedding) {
+		t.Fatal("expected embedding-only document with metadata, got", doc)
+	}
+
+	// Both kinds are found by queries
+	res, err := c.Query(ctx, "hello", 3, nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	gotIDs := []string{res[0].ID, res[1].ID, res[2].ID}
+	if !slices.Equal([]string{"text", "image", "audio"}, gotIDs) {
+		t.Fatal("expected [text image audio], got", gotIDs)
+	}
+	res, err = c.QueryEmbedding(ctx, []float32{0, 0.1, 1}, 1, nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if res[0].ID != "audio" {
+		t.Fatal("expected audio, got", res[0].ID)
+	}
+
+	// Errors
+	err = c.AddEmbeddingOnly(ctx, "empty", nil, nil)
+	if err == nil {
+		t.Fatal("expected error for empty embedding, got nil")
+	}
+	err = c.AddEmbeddingOnly(ctx, "", []float32{1, 0, 0}, nil)
+	if err == nil {
+		t.Fatal("expected error for empty ID, got nil")
+	}
+}
+
 func TestCollection_QueryError(t *testing.T) {
 	// Create collection
 	db := NewDB()
@@ -445,6 +577,71 @@ func TestCollection_Get(t *testing.T) {
 	}
 }
 
+func TestCollection_DocumentChannel(t *testing.T) {
+	ctx := context.Background()
+	vectors := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return vectors, nil
+	}
+
+	// Create collection
+	db := NewDB()
+	c, err := db.CreateCollection("test", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.Add(ctx, []string{"1", "2", "3"}, nil, nil, []string{"hello world", "hallo welt", "hola mundo"})
+	if err != nil {
+		t.Fatal("expected nil, got", err)
+	}
+
+	t.Run("Snapshot", func(t *testing.T) {
+		docChan := c.DocumentChannel(ctx, 0)
+
+		// Adding a document must not be blocked while iterating, and the new
+		// document must not be part of the snapshot.
+		err := c.AddDocument(ctx, Document{ID: "4", Content: "bonjour le monde"})
+		if err != nil {
+			t.Fatal("expected nil, got", err)
+		}
+
+		var gotIDs []string
+		for doc := range docChan {
+			gotIDs = append(gotIDs, doc.ID)
+			// Modifying the copy must not affect the collection
+			doc.Content = "modified"
+		}
+		slices.Sort(gotIDs)
+		if !slices.Equal([]string{"1", "2", "3"}, gotIDs) {
+			t.Fatal("expected [1 2 3], got", gotIDs)
+		}
+		doc, err := c.GetByID(ctx, "1")
+		if err != nil {
+			t.Fatal("expected nil, got", err)
+		}
+		if doc.Content != "hello world" {
+			t.Fatal("expected hello world, got", doc.Content)
+		}
+	})
+
+	t.Run("Canceled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		docChan := c.DocumentChannel(ctx, 0)
+		<-docChan
+		cancel()
+
+		// The channel must be closed without sending the remaining documents.
+		// With an unbuffered channel at most one more can be in flight.
+		n := 0
+		for range docChan {
+			n++
+		}
+		if n > 1 {
+			t.Fatal("expected at most 1 more document, got", n)
+		}
+	})
+}
+
 func TestCollection_Count(t *testing.T) {
 	// Create collection
 	db := NewDB()
@@ -564,6 +761,521 @@ func TestCollection_Delete(t *testing.T) {
 	checkCount(0)
 }
 
+func TestCollection_QueryDryRun(t *testing.T) {
+	ctx := context.Background()
+	vectors := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+	embeddingCalls := 0
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		embeddingCalls++
+		return vectors, nil
+	}
+
+	// Create collection
+	db := NewDB()
+	c, err := db.CreateCollection("test", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.Add(ctx, []string{"1", "2", "3"}, nil, nil, []string{"hello world", "hallo welt", "hola mundo"})
+	if err != nil {
+		t.Fatal("expected nil, got", err)
+	}
+	embeddingCalls = 0
+
+	queryText := "What is the capital of France?"
+	est := c.QueryDryRun(ctx, queryText, 2)
+	if est.EmbeddingAPICallsRequired != 1 {
+		t.Fatal("expected 1, got", est.EmbeddingAPICallsRequired)
+	}
+	if est.DocumentsToScan != 3 {
+		t.Fatal("expected 3, got", est.DocumentsToScan)
+	}
+	if est.EstimatedInputTokens <= 0 {
+		t.Fatal("expected > 0, got", est.EstimatedInputTokens)
+	}
+	if embeddingCalls != 0 {
+		t.Fatal("expected no embedding calls, got", embeddingCalls)
+	}
+
+	// The token estimate must scale linearly with the text length
+	for _, factor := range []int{2, 10, 100} {
+		longEst := c.QueryDryRun(ctx, strings.Repeat(queryText, factor), 2)
+		if longEst.EstimatedInputTokens < factor*est.EstimatedInputTokens-factor ||
+			longEst.EstimatedInputTokens > factor*est.EstimatedInputTokens {
+			t.Fatalf("expected about %d tokens, got %d", factor*est.EstimatedInputTokens, longEst.EstimatedInputTokens)
+		}
+	}
+
+	// Cost
+	wantCost := float64(est.EstimatedInputTokens) * DefaultEmbeddingPricePerToken
+	if est.EstimatedCostUSD != wantCost {
+		t.Fatal("expected", wantCost, "got", est.EstimatedCostUSD)
+	}
+	c.SetEmbeddingPricePerToken(0.001)
+	est = c.QueryDryRun(ctx, queryText, 2)
+	wantCost = float64(est.EstimatedInputTokens) * 0.001
+	if est.EstimatedCostUSD != wantCost {
+		t.Fatal("expected", wantCost, "got", est.EstimatedCostUSD)
+	}
+
+	// Filters and passed embeddings
+	est = c.QueryWithOptionsDryRun(ctx, QueryOptions{
+		QueryEmbedding: vectors,
+		NResults:       2,
+		Where:          map[string]string{"missing": "value"},
+	})
+	if est.EmbeddingAPICallsRequired != 0 || est.EstimatedInputTokens != 0 {
+		t.Fatal("expected no embedding calls and tokens, got", est.EmbeddingAPICallsRequired, est.EstimatedInputTokens)
+	}
+	if est.DocumentsToScan != 0 {
+		t.Fatal("expected 0, got", est.DocumentsToScan)
+	}
+	est = c.QueryWithOptionsDryRun(ctx, QueryOptions{
+		QueryText:     queryText,
+		NResults:      1,
+		WhereDocument: map[string]string{"$contains": "hallo"},
+		Negative:      NegativeQueryOptions{Mode: NEGATIVE_MODE_SUBTRACT, Text: queryText},
+	})
+	if est.EmbeddingAPICallsRequired != 2 {
+		t.Fatal("expected 2, got", est.EmbeddingAPICallsRequired)
+	}
+	if est.DocumentsToScan != 1 {
+		t.Fatal("expected 1, got", est.DocumentsToScan)
+	}
+}
+
+func TestCollection_QueryWithTemporalDecay(t *testing.T) {
+	ctx := context.Background()
+	vectors := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return vectors, nil
+	}
+	now := time.Now()
+	docs := []Document{
+		{
+			ID:        "old",
+			Metadata:  map[string]string{"published": now.AddDate(-1, 0, 0).Format(time.RFC3339)},
+			Embedding: vectors,
+		},
+		{
+			ID: "new",
+			// Not exactly now, so that the decay isn't lost in float32 precision
+			Metadata:  map[string]string{"published": now.Add(-time.Hour).Format(time.RFC3339)},
+			Embedding: vectors,
+		},
+		{
+			ID:        "no-timestamp",
+			Embedding: vectors,
+		},
+	}
+
+	t.Run("With decay", func(t *testing.T) {
+		db := NewDB()
+		c, err := db.CreateCollection("test", nil, embeddingFunc, WithTemporalDecay(30*24*time.Hour, "published"))
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		err = c.AddDocuments(ctx, docs, 1)
+		if err != nil {
+			t.Fatal("expected nil, got", err)
+		}
+
+		res, err := c.Query(ctx, "foo", 3, nil, nil)
+		if err != nil {
+			t.Fatal("expected nil, got", err)
+		}
+		if len(res) != 3 {
+			t.Fatal("expected 3 results, got", len(res))
+		}
+		// The document without a timestamp isn't decayed, and the new one only
+		// by a tiny bit.
+		if res[0].ID != "no-timestamp" {
+			t.Fatal("expected no-timestamp, got", res[0].ID)
+		}
+		if res[1].ID != "new" {
+			t.Fatal("expected new, got", res[1].ID)
+		}
+		if res[2].ID != "old" {
+			t.Fatal("expected old, got", res[2].ID)
+		}
+		// One year is about 12 half-lives, so the similarity is close to 0.
+		if res[2].Similarity < 0 || res[2].Similarity > 0.001 {
+			t.Fatal("expected similarity of about 0, got", res[2].Similarity)
+		}
+	})
+
+	t.Run("Negative similarity", func(t *testing.T) {
+		db := NewDB()
+		c, err := db.CreateCollection("test", nil, embeddingFunc, WithTemporalDecay(30*24*time.Hour, "published"))
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		err = c.AddDocuments(ctx, docs[:2], 1)
+		if err != nil {
+			t.Fatal("expected nil, got", err)
+		}
+
+		// The query is dissimilar to both documents. Negative similarities
+		// decay towards 0 as well, so the old one ranks higher.
+		res, err := c.QueryEmbedding(ctx, []float32{1, -1, 0}, 2, nil, nil)
+		if err != nil {
+			t.Fatal("expected nil, got", err)
+		}
+		if len(res) != 2 {
+			t.Fatal("expected 2 results, got", len(res))
+		}
+		if res[0].ID != "old" || res[1].ID != "new" {
+			t.Fatal("expected old before new, got", res[0].ID, res[1].ID)
+		}
+		if res[1].Similarity >= 0 || res[0].Similarity < res[1].Similarity/1000 {
+			t.Fatal("expected negative similarities, with the old one close to 0, got", res[0].Similarity, res[1].Similarity)
+		}
+	})
+
+	t.Run("Without decay", func(t *testing.T) {
+		db := NewDB()
+		c, err := db.CreateCollection("test", nil, embeddingFunc)
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		err = c.AddDocuments(ctx, docs, 1)
+		if err != nil {
+			t.Fatal("expected nil, got", err)
+		}
+
+		res, err := c.Query(ctx, "foo", 3, nil, nil)
+		if err != nil {
+			t.Fatal("expected nil, got", err)
+		}
+		for _, r := range res {
+			if r.Similarity < 0.999 {
+				t.Fatal("expected similarity of about 1, got", r.Similarity)
+			}
+		}
+	})
+}
+
+func TestCollection_QueryHybrid(t *testing.T) {
+	ctx := context.Background()
+
+	// Create collection
+	db := NewDB()
+	c, err := db.CreateCollection("test", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	// Add documents
+	err = c.AddHybrid(ctx, "a", []float32{1, 0, 0}, map[uint32]float32{5: 1}, nil, "")
+	if err != nil {
+		t.Fatal("expected nil, got", err)
+	}
+	err = c.AddHybrid(ctx, "b", []float32{0, 1, 0}, map[uint32]float32{1: 1, 2: 1}, nil, "")
+	if err != nil {
+		t.Fatal("expected nil, got", err)
+	}
+	err = c.AddHybridDocument(ctx, HybridDocument{
+		ID:           "c",
+		Metadata:     map[string]string{"foo": "bar"},
+		DenseVector:  []float32{0.7, 0.7, 0},
+		SparseVector: map[uint32]float32{1: 3}, // Gets normalized
+	})
+	if err != nil {
+		t.Fatal("expected nil, got", err)
+	}
+
+	denseQuery := []float32{1, 0, 0}
+	sparseQuery := map[uint32]float32{1: 1}
+
+	tt := []struct {
+		name    string
+		alpha   float32
+		where   map[string]string
+		wantIDs []string
+	}{
+		{
+			name:    "Pure dense",
+			alpha:   1,
+			wantIDs: []string{"a", "c", "b"},
+		},
+		{
+			name:    "Pure sparse",
+			alpha:   0,
+			wantIDs: []string{"c", "b", "a"},
+		},
+		{
+			name:    "Hybrid",
+			alpha:   0.5,
+			wantIDs: []string{"c", "a", "b"},
+		},
+		{
+			name:    "Hybrid with filter",
+			alpha:   0.5,
+			where:   map[string]string{"foo": "bar"},
+			wantIDs: []string{"c"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := c.QueryHybrid(ctx, denseQuery, sparseQuery, tc.alpha, 3, tc.where)
+			if err != nil {
+				t.Fatal("expected nil, got", err)
+			}
+			gotIDs := make([]string, 0, len(res))
+			for _, r := range res {
+				gotIDs = append(gotIDs, r.ID)
+			}
+			if !slices.Equal(tc.wantIDs, gotIDs) {
+				t.Fatal("expected", tc.wantIDs, "got", gotIDs)
+			}
+		})
+	}
+
+	// Pure sparse search must have the normalized sparse similarity
+	res, err := c.QueryHybrid(ctx, nil, sparseQuery, 0, 1, nil)
+	if err != nil {
+		t.Fatal("expected nil, got", err)
+	}
+	if res[0].Similarity < 0.999 || res[0].Similarity > 1.001 {
+		t.Fatal("expected similarity of about 1, got", res[0].Similarity)
+	}
+
+	// Errors
+	_, err = c.QueryHybrid(ctx, denseQuery, sparseQuery, 1.5, 3, nil)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	_, err = c.QueryHybrid(ctx, nil, sparseQuery, 0.5, 3, nil)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	_, err = c.QueryHybrid(ctx, denseQuery, nil, 0.5, 3, nil)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestCollection_QueryThenSort(t *testing.T) {
+	ctx := context.Background()
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return []float32{1, 0, 0}, nil
+	}
+
+	// Create collection
+	db := NewDB()
+	c, err := db.CreateCollection("test", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	// The similarity order is 1, 2, 3, while the popularity order is 3, 2, 1.
+	err = c.Add(ctx, []string{"1", "2", "3"}, [][]float32{{1, 0, 0}, {1, 1, 0}, {1, 1, 1}}, []map[string]string{
+		{"popularity": "3"},
+		{"popularity": "20"},
+		{"popularity": "100"},
+	}, nil)
+	if err != nil {
+		t.Fatal("expected nil, got", err)
+	}
+
+	byPopularity := func(a, b Result) bool {
+		pa, _ := strconv.Atoi(a.Metadata["popularity"])
+		pb, _ := strconv.Atoi(b.Metadata["popularity"])
+		return pa > pb
+	}
+
+	res, err := c.Query(ctx, "foo", 3, nil, nil)
+	if err != nil {
+		t.Fatal("expected nil, got", err)
+	}
+	sorted := SortResults(res, byPopularity)
+	gotIDs := []string{sorted[0].ID, sorted[1].ID, sorted[2].ID}
+	if !slices.Equal([]string{"3", "2", "1"}, gotIDs) {
+		t.Fatal("expected [3 2 1], got", gotIDs)
+	}
+	// The original results must be unchanged
+	gotIDs = []string{res[0].ID, res[1].ID, res[2].ID}
+	if !slices.Equal([]string{"1", "2", "3"}, gotIDs) {
+		t.Fatal("expected [1 2 3], got", gotIDs)
+	}
+
+	res, err = c.QueryThenSort(ctx, "foo", 2, nil, nil, byPopularity)
+	if err != nil {
+		t.Fatal("expected nil, got", err)
+	}
+	// Only the top 2 by similarity are sorted
+	gotIDs = []string{res[0].ID, res[1].ID}
+	if !slices.Equal([]string{"2", "1"}, gotIDs) {
+		t.Fatal("expected [2 1], got", gotIDs)
+	}
+}
+
+func TestCollection_QuerySoftmax(t *testing.T) {
+	ctx := context.Background()
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return []float32{1, 0, 0}, nil
+	}
+
+	db := NewDB()
+	c, err := db.CreateCollection("test", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.Add(ctx, []string{"1", "2", "3", "4"}, [][]float32{{1, 0, 0}, {1, 1, 0}, {1, 1, 1}, {0, 1, 0}}, nil, nil)
+	if err != nil {
+		t.Fatal("expected nil, got", err)
+	}
+
+	sum := func(res []Result) float64 {
+		var s float64
+		for _, r := range res {
+			s += float64(r.Similarity)
+		}
+		return s
+	}
+
+	for _, temperature := range []float32{0.01, 0.1, 1, 10} {
+		res, err := c.QuerySoftmax(ctx, "foo", 3, temperature, nil, nil)
+		if err != nil {
+			t.Fatal("expected nil, got", err)
+		}
+		if len(res) != 3 {
+			t.Fatal("expected 3 results, got", len(res))
+		}
+		// The order is unchanged and the probabilities sum up to 1
+		gotIDs := []string{res[0].ID, res[1].ID, res[2].ID}
+		if !slices.Equal([]string{"1", "2", "3"}, gotIDs) {
+			t.Fatal("expected [1 2 3], got", gotIDs)
+		}
+		if s := sum(res); math.Abs(s-1) > 1e-6 {
+			t.Fatal("expected probabilities to sum up to 1, got", s)
+		}
+		for _, r := range res {
+			if r.Similarity < 0 || r.Similarity > 1 {
+				t.Fatal("expected probability in [0, 1], got", r.Similarity)
+			}
+		}
+	}
+
+	// A low temperature puts almost all probability mass on the top result
+	res, err := c.QuerySoftmax(ctx, "foo", 3, 0.01, nil, nil)
+	if err != nil {
+		t.Fatal("expected nil, got", err)
+	}
+	if res[0].Similarity < 0.99 {
+		t.Fatal("expected probability of top result > 0.99, got", res[0].Similarity)
+	}
+	// A high temperature distributes it more evenly
+	res, err = c.QuerySoftmax(ctx, "foo", 3, 10, nil, nil)
+	if err != nil {
+		t.Fatal("expected nil, got", err)
+	}
+	if res[0].Similarity > 0.4 {
+		t.Fatal("expected probability of top result < 0.4, got", res[0].Similarity)
+	}
+
+	// Errors
+	for _, temperature := range []float32{0, -1} {
+		_, err = c.QuerySoftmax(ctx, "foo", 3, temperature, nil, nil)
+		if err == nil {
+			t.Fatal("expected error for temperature", temperature, "got nil")
+		}
+	}
+}
+
+func TestCollection_QueryAll(t *testing.T) {
+	ctx := context.Background()
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return []float32{1, 0, 0}, nil
+	}
+
+	// Create collection
+	db := NewDB()
+	c, err := db.CreateCollection("test", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.Add(ctx, []string{"1", "2", "3", "4"}, [][]float32{{1, 1, 1}, {1, 0, 0}, {0, 1, 0}, {1, 1, 0}}, []map[string]string{
+		{"lang": "en"},
+		{"lang": "de"},
+		{"lang": "en"},
+		{"lang": "en"},
+	}, []string{"hello world", "hallo welt", "hello again", "hello"})
+	if err != nil {
+		t.Fatal("expected nil, got", err)
+	}
+
+	t.Run("No filter", func(t *testing.T) {
+		res, err := c.QueryAll(ctx, "foo", nil, nil)
+		if err != nil {
+			t.Fatal("expected nil, got", err)
+		}
+		if len(res) != c.Count() {
+			t.Fatal("expected", c.Count(), "results, got", len(res))
+		}
+		gotIDs := []string{res[0].ID, res[1].ID, res[2].ID, res[3].ID}
+		if !slices.Equal([]string{"2", "4", "1", "3"}, gotIDs) {
+			t.Fatal("expected [2 4 1 3], got", gotIDs)
+		}
+	})
+
+	t.Run("With filter", func(t *testing.T) {
+		res, err := c.QueryAll(ctx, "foo", map[string]string{"lang": "en"}, map[string]string{"$contains": "world"})
+		if err != nil {
+			t.Fatal("expected nil, got", err)
+		}
+		if len(res) != 1 {
+			t.Fatal("expected 1 result, got", len(res))
+		}
+		if res[0].ID != "1" {
+			t.Fatal("expected 1, got", res[0].ID)
+		}
+	})
+}
+
+func TestCollection_Query_ContentLenMetadataCount(t *testing.T) {
+	ctx := context.Background()
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return []float32{1, 0, 0}, nil
+	}
+
+	db := NewDB()
+	c, err := db.CreateCollection("test", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	docs := []Document{
+		{ID: "1", Embedding: []float32{1, 0, 0}, Content: "hello world", Metadata: map[string]string{"lang": "en", "source": "web"}},
+		{ID: "2", Embedding: []float32{0, 1, 0}, Content: "hallö welt", Metadata: map[string]string{"lang": "de"}},
+		{ID: "3", Embedding: []float32{0, 0, 1}},
+	}
+	err = c.AddDocuments(ctx, docs, 1)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	res, err := c.QueryAll(ctx, "foo", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if len(res) != len(docs) {
+		t.Fatal("expected", len(docs), "results, got", len(res))
+	}
+	for _, r := range res {
+		doc, err := c.GetByID(ctx, r.ID)
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		if r.ContentLen != len(doc.Content) {
+			t.Fatal("expected content length", len(doc.Content), "got", r.ContentLen)
+		}
+		if r.MetadataCount != len(doc.Metadata) {
+			t.Fatal("expected metadata count", len(doc.Metadata), "got", r.MetadataCount)
+		}
+	}
+}
+
 // Global var for assignment in the benchmark to avoid compiler optimizations.
 var globalRes []Result
 
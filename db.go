@@ -21,6 +21,10 @@ import (
 // others like Nomic's "nomic-embed-text-v1.5" don't.
 type EmbeddingFunc func(ctx context.Context, text string) ([]float32, error)
 
+// ErrCollectionAlreadyExists is returned by [DB.CreateCollection] if a collection
+// with the given name already exists.
+var ErrCollectionAlreadyExists = errors.New("collection already exists")
+
 // DB is the chromem-go database. It holds collections, which hold documents.
 //
 //	+----+    1-n    +------------+    n-n    +----------+
@@ -32,6 +36,17 @@ type DB struct {
 
 	persistDirectory string
 	compress         bool
+	checksum         bool
+
+	// Registered via [DB.AddCollectionObserver], guarded by collectionsLock
+	observers []CollectionObserver
+	// Names of the namespaces created via [DB.CreateNamespace], guarded by
+	// collectionsLock
+	namespaces map[string]struct{}
+	// Set via [WithLoadErrorHandler], only used in NewPersistentDB
+	loadErrorHandler func(LoadError)
+	// Set via [WithReadOnlyLoad], only used in NewPersistentDB
+	readOnlyLoad bool
 
 	// ⚠️ When adding fields here, consider adding them to the persistence struct
 	// versions in [DB.Export] and [DB.Import] as well!
@@ -44,12 +59,90 @@ type DB struct {
 func NewDB() *DB {
 	return &DB{
 		collections: make(map[string]*Collection),
+		namespaces:  make(map[string]struct{}),
+	}
+}
+
+// DBOption is an option for a persistent DB. See [NewPersistentDB].
+type DBOption func(*DB)
+
+// WithChecksumVerification makes the DB store a CRC32 checksum of each persisted
+// document in a sidecar file next to the document file. When loading the DB,
+// the checksums are verified, and a mismatch leads to an [ErrChecksumMismatch].
+// This detects silently corrupted files, for example after a power loss.
+// Documents without a checksum file, for example because they were persisted
+// before the option was enabled, are loaded without verification.
+func WithChecksumVerification() DBOption {
+	return func(db *DB) {
+		db.checksum = true
 	}
 }
 
+// LoadError is a collection or document that couldn't be loaded by
+// [NewPersistentDB]. See [WithLoadErrorHandler].
+type LoadError struct {
+	// The path of the collection directory
+	CollectionPath string
+	// The name of the collection. Empty if the whole collection couldn't be
+	// loaded.
+	Collection string
+	// The path of the document file. Empty if the whole collection couldn't be
+	// loaded.
+	File string
+	Err  error
+}
+
+func (e LoadError) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("document %s of collection %q: %v", e.File, e.Collection, e.Err)
+	}
+	return fmt.Sprintf("collection %s: %v", e.CollectionPath, e.Err)
+}
+
+func (e LoadError) Unwrap() error {
+	return e.Err
+}
+
+// WithLoadErrorHandler makes [NewPersistentDB] skip collections and documents
+// that can't be loaded, instead of failing, and call the handler for each of
+// them. This includes files that can't be read or decoded and checksum
+// mismatches (see [WithChecksumVerification]). It's useful for tools that
+// check or recover a DB, but regular applications should usually fail instead
+// of silently working with incomplete data.
+// The handler is called synchronously during the loading.
+func WithLoadErrorHandler(handler func(LoadError)) DBOption {
+	return func(db *DB) {
+		db.loadErrorHandler = handler
+	}
+}
+
+// WithReadOnlyLoad makes [NewPersistentDB] load the DB without modifying any
+// files. It doesn't replay write-ahead logs, doesn't complete an interrupted
+// redistribution of documents to shards and doesn't remove left over cold
+// document files. If the directory doesn't exist, it returns an error instead
+// of creating it.
+// As mutations in write-ahead logs are missing, the loaded DB must not be
+// written to. It's useful for tools that inspect a DB, like a validator.
+func WithReadOnlyLoad() DBOption {
+	return func(db *DB) {
+		db.readOnlyLoad = true
+	}
+}
+
+// handleLoadError passes the error to the load error handler and returns nil,
+// or returns the error if there's no handler.
+func (db *DB) handleLoadError(err LoadError) error {
+	if db.loadErrorHandler == nil {
+		return err.Err
+	}
+	db.loadErrorHandler(err)
+	return nil
+}
+
 // NewPersistentDB creates a new persistent chromem-go DB.
 // If the path is empty, it defaults to "./chromem-go".
 // If compress is true, the files are compressed with gzip.
+// Optional options like [WithChecksumVerification] can be passed as well.
 //
 // The persistence covers the collections (including their documents) and the metadata.
 // However, it doesn't cover the EmbeddingFunc, as functions can't be serialized.
@@ -65,7 +158,7 @@ func NewDB() *DB {
 // [DB.ExportToFile] / [DB.ExportToWriter] and [DB.ImportFromFile] /
 // [DB.ImportFromReader] to export and import the entire DB to/from a file or
 // writer/reader, which also works for the pure in-memory DB.
-func NewPersistentDB(path string, compress bool) (*DB, error) {
+func NewPersistentDB(path string, compress bool, opts ...DBOption) (*DB, error) {
 	if path == "" {
 		path = "./chromem-go"
 	} else {
@@ -81,14 +174,21 @@ func NewPersistentDB(path string, compress bool) (*DB, error) {
 
 	db := &DB{
 		collections:      make(map[string]*Collection),
+		namespaces:       make(map[string]struct{}),
 		persistDirectory: path,
 		compress:         compress,
 	}
+	for _, opt := range opts {
+		opt(db)
+	}
 
 	// If the directory doesn't exist, create it and return an empty DB.
 	fi, err := os.Stat(path)
 	if err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
+			if db.readOnlyLoad {
+				return nil, fmt.Errorf("persistence directory doesn't exist: %s", path)
+			}
 			err := os.MkdirAll(path, 0o700)
 			if err != nil {
 				return nil, fmt.Errorf("couldn't create persistence directory: %w", err)
@@ -102,82 +202,218 @@ func NewPersistentDB(path string, compress bool) (*DB, error) {
 	}
 
 	// Otherwise, read all collections and their documents from the directory.
+	// Collections are subdirectories, so skip any files (which the user might
+	// have placed). The collections of namespaces are in subdirectories of the
+	// namespace directories.
 	dirEntries, err := os.ReadDir(path)
 	if err != nil {
 		return nil, fmt.Errorf("couldn't read persistence directory: %w", err)
 	}
+	var collectionPaths []string
 	for _, dirEntry := range dirEntries {
-		// Collections are subdirectories, so skip any files (which the user might
-		// have placed).
-		if !dirEntry.IsDir() {
-			continue
+		if dirEntry.IsDir() && dirEntry.Name() != namespacesDirName {
+			collectionPaths = append(collectionPaths, filepath.Join(path, dirEntry.Name()))
+		}
+	}
+	namespacePaths, err := db.loadNamespaces()
+	if err != nil {
+		return nil, err
+	}
+	for _, namespacePath := range namespacePaths {
+		dirEntries, err := os.ReadDir(namespacePath)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read namespace directory: %w", err)
+		}
+		for _, dirEntry := range dirEntries {
+			if dirEntry.IsDir() {
+				collectionPaths = append(collectionPaths, filepath.Join(namespacePath, dirEntry.Name()))
+			}
 		}
-		// For each subdirectory, create a collection and read its name, metadata
-		// and documents.
+	}
+	for _, collectionPath := range collectionPaths {
 		// TODO: Parallelize this (e.g. chan with $numCPU buffer and $numCPU goroutines
 		// reading from it).
-		collectionPath := filepath.Join(path, dirEntry.Name())
-		collectionDirEntries, err := os.ReadDir(collectionPath)
+		c, err := db.loadCollection(collectionPath, ext)
 		if err != nil {
-			return nil, fmt.Errorf("couldn't read collection directory: %w", err)
+			err = db.handleLoadError(LoadError{CollectionPath: collectionPath, Err: err})
+			if err != nil {
+				return nil, err
+			}
+			continue
 		}
-		c := &Collection{
-			documents:        make(map[string]*Document),
-			persistDirectory: collectionPath,
-			compress:         compress,
-			// We can fill Name and metadata only after reading
-			// the metadata.
-			// We can fill embed only when the user calls DB.GetCollection() or
-			// DB.GetOrCreateCollection().
-		}
-		for _, collectionDirEntry := range collectionDirEntries {
-			// Files should be metadata and documents; skip subdirectories which
-			// the user might have placed.
-			if collectionDirEntry.IsDir() {
-				continue
+		// It was likely a user-added directory
+		if c == nil {
+			continue
+		}
+		db.collections[c.Name] = c
+	}
+
+	return db, nil
+}
+
+// loadCollection reads the collection with its name, metadata and documents
+// from the given collection directory. It returns nil if the directory is
+// neither a collection nor contains documents, like a directory that the user
+// has placed. Documents that can't be loaded are passed to the load error
+// handler if there is one, see [WithLoadErrorHandler].
+func (db *DB) loadCollection(collectionPath, ext string) (*Collection, error) {
+	collectionDirEntries, err := os.ReadDir(collectionPath)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read collection directory: %w", err)
+	}
+	c := &Collection{
+		documents:        make(map[string]*Document),
+		persistDirectory: collectionPath,
+		compress:         db.compress,
+		checksum:         db.checksum,
+
+		embeddingPricePerToken: DefaultEmbeddingPricePerToken,
+		// We can fill Name and metadata only after reading
+		// the metadata.
+		// We can fill embed only when the user calls DB.GetCollection() or
+		// DB.GetOrCreateCollection().
+	}
+	// Errors of single documents are only passed to the handler after the
+	// collection name is known.
+	var docErrs []LoadError
+	skipDocument := func(fPath string, err error) error {
+		if db.loadErrorHandler == nil {
+			return err
+		}
+		docErrs = append(docErrs, LoadError{CollectionPath: collectionPath, File: fPath, Err: err})
+		return nil
+	}
+	type persistedDocFile struct {
+		path string
+		doc  *persistedDocument
+	}
+	var persistedDocs []persistedDocFile
+	readDocument := func(fPath string) error {
+		if db.checksum {
+			err := verifyChecksum(fPath)
+			if err != nil {
+				return skipDocument(fPath, fmt.Errorf("couldn't verify document: %w", err))
 			}
+		}
+		// Delta-encoded documents can only be decoded after the metadata
+		// with the reference vector is read.
+		pd := &persistedDocument{}
+		err := readFromFile(fPath, pd, "")
+		if err != nil {
+			return skipDocument(fPath, fmt.Errorf("couldn't read document: %w", err))
+		}
+		persistedDocs = append(persistedDocs, persistedDocFile{path: fPath, doc: pd})
+		return nil
+	}
+	var shardPaths []string
+	for _, collectionDirEntry := range collectionDirEntries {
+		// Files should be metadata and documents; skip subdirectories which
+		// the user might have placed, except for shards.
+		if collectionDirEntry.IsDir() {
+			if _, ok := parseShardDirName(collectionDirEntry.Name()); ok {
+				shardPaths = append(shardPaths, filepath.Join(collectionPath, collectionDirEntry.Name()))
+			}
+			continue
+		}
 
-			fPath := filepath.Join(collectionPath, collectionDirEntry.Name())
-			// Differentiate between collection metadata, documents and other files.
-			if collectionDirEntry.Name() == metadataFileName+ext {
-				// Read name and metadata
-				pc := struct {
-					Name     string
-					Metadata map[string]string
-				}{}
-				err := readFromFile(fPath, &pc, "")
-				if err != nil {
-					return nil, fmt.Errorf("couldn't read collection metadata: %w", err)
-				}
-				c.Name = pc.Name
-				c.metadata = pc.Metadata
-			} else if strings.HasSuffix(collectionDirEntry.Name(), ext) {
-				// Read document
-				d := &Document{}
-				err := readFromFile(fPath, d, "")
-				if err != nil {
-					return nil, fmt.Errorf("couldn't read document: %w", err)
-				}
-				c.documents[d.ID] = d
-			} else {
-				// Might be a file that the user has placed
+		fPath := filepath.Join(collectionPath, collectionDirEntry.Name())
+		// Differentiate between collection metadata, documents and other files.
+		if collectionDirEntry.Name() == metadataFileName+ext {
+			// Read name and metadata
+			pc := persistedCollectionMetadata{}
+			err := readFromFile(fPath, &pc, "")
+			if err != nil {
+				return nil, fmt.Errorf("couldn't read collection metadata: %w", err)
+			}
+			c.Name = pc.Name
+			c.metadata = pc.Metadata
+			c.walPath = pc.WALPath
+			c.deltaReference = pc.DeltaReference
+			c.prevDeltaReference = pc.PrevDeltaReference
+			c.shards = pc.Shards
+		} else if strings.HasSuffix(collectionDirEntry.Name(), ext) {
+			// Read document. In sharded collections, there are only documents
+			// in the collection directory if sharding was interrupted.
+			err := readDocument(fPath)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			// Might be a file that the user has placed
+			continue
+		}
+	}
+	// Read the documents of sharded collections
+	for _, shardPath := range shardPaths {
+		shardDirEntries, err := os.ReadDir(shardPath)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read shard directory: %w", err)
+		}
+		for _, shardDirEntry := range shardDirEntries {
+			if shardDirEntry.IsDir() || !strings.HasSuffix(shardDirEntry.Name(), ext) {
 				continue
 			}
+			err := readDocument(filepath.Join(shardPath, shardDirEntry.Name()))
+			if err != nil {
+				return nil, err
+			}
 		}
-		// If we have neither name nor documents, it was likely a user-added
-		// directory, so skip it.
-		if c.Name == "" && len(c.documents) == 0 {
+	}
+	for _, pd := range persistedDocs {
+		d, err := c.toDocument(pd.doc)
+		if err != nil {
+			err = skipDocument(pd.path, fmt.Errorf("couldn't decode document: %w", err))
+			if err != nil {
+				return nil, err
+			}
 			continue
 		}
-		// If we have no name, it means there was no metadata file
-		if c.Name == "" {
-			return nil, fmt.Errorf("collection metadata file not found: %s", collectionPath)
+		c.documents[d.ID] = d
+	}
+	// If we have neither name nor documents, it was likely a user-added
+	// directory, so skip it.
+	if c.Name == "" && len(c.documents) == 0 && len(docErrs) == 0 {
+		return nil, nil
+	}
+	// If we have no name, it means there was no metadata file
+	if c.Name == "" {
+		return nil, fmt.Errorf("collection metadata file not found: %s", collectionPath)
+	}
+	// Complete a redistribution of the documents that was interrupted. If
+	// all documents are in their shard, this doesn't move any files.
+	if c.shards > 0 && !db.readOnlyLoad {
+		err = c.moveToShards()
+		if err != nil {
+			return nil, fmt.Errorf("couldn't move documents of collection %q to their shards: %w", c.Name, err)
+		}
+	}
+	// Replay the write-ahead log, which might contain mutations that didn't
+	// make it to the document files. Even if the WAL isn't enabled (anymore),
+	// a WAL file alongside the collection directory is replayed.
+	if !db.readOnlyLoad {
+		walPath := c.walPath
+		if walPath == "" {
+			walPath = collectionPath + walFileExt
+		}
+		err = c.replayWAL(walPath, true)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't replay WAL of collection %q: %w", c.Name, err)
+		}
+	}
+	// Cold documents are read after the WAL replay, because hot documents
+	// take precedence.
+	if fi, err := os.Stat(filepath.Join(collectionPath, coldDirName)); err == nil && fi.IsDir() {
+		err = c.loadColdDocuments(ext, !db.readOnlyLoad, skipDocument)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't load cold documents of collection %q: %w", c.Name, err)
 		}
-
-		db.collections[c.Name] = c
 	}
 
-	return db, nil
+	for _, docErr := range docErrs {
+		docErr.Collection = c.Name
+		db.loadErrorHandler(docErr)
+	}
+	return c, nil
 }
 
 // Import imports the DB from a file at the given path. The file must be encoded
@@ -257,19 +493,21 @@ func (db *DB) ImportFromFile(filePath string, encryptionKey string, collections
 
 			metadata:  pc.Metadata,
 			documents: pc.Documents,
+
+			embeddingPricePerToken: DefaultEmbeddingPricePerToken,
 		}
 		if db.persistDirectory != "" {
-			c.persistDirectory = filepath.Join(db.persistDirectory, hash2hex(pc.Name))
+			c.persistDirectory = filepath.Join(db.collectionParentDir(pc.Name), hash2hex(pc.Name))
 			c.compress = db.compress
+			c.checksum = db.checksum
 			err = c.persistMetadata()
 			if err != nil {
 				return fmt.Errorf("couldn't persist collection metadata: %w", err)
 			}
 			for _, doc := range c.documents {
-				docPath := c.getDocPath(doc.ID)
-				err = persistToFile(docPath, doc, c.compress, "")
+				err = c.persistDocument(doc)
 				if err != nil {
-					return fmt.Errorf("couldn't persist document to %q: %w", docPath, err)
+					return err
 				}
 			}
 		}
@@ -332,19 +570,21 @@ func (db *DB) ImportFromReader(reader io.ReadSeeker, encryptionKey string, colle
 
 			metadata:  pc.Metadata,
 			documents: pc.Documents,
+
+			embeddingPricePerToken: DefaultEmbeddingPricePerToken,
 		}
 		if db.persistDirectory != "" {
-			c.persistDirectory = filepath.Join(db.persistDirectory, hash2hex(pc.Name))
+			c.persistDirectory = filepath.Join(db.collectionParentDir(pc.Name), hash2hex(pc.Name))
 			c.compress = db.compress
+			c.checksum = db.checksum
 			err = c.persistMetadata()
 			if err != nil {
 				return fmt.Errorf("couldn't persist collection metadata: %w", err)
 			}
 			for _, doc := range c.documents {
-				docPath := c.getDocPath(doc.ID)
-				err := persistToFile(docPath, doc, c.compress, "")
+				err := c.persistDocument(doc)
 				if err != nil {
-					return fmt.Errorf("couldn't persist document to %q: %w", docPath, err)
+					return err
 				}
 			}
 		}
@@ -416,10 +656,17 @@ func (db *DB) ExportToFile(filePath string, compress bool, encryptionKey string,
 
 	for k, v := range db.collections {
 		if len(collections) == 0 || slices.Contains(collections, k) {
+			// Cold documents are exported including their embeddings
+			v.documentsLock.RLock()
+			docs, err := v.allDocuments()
+			v.documentsLock.RUnlock()
+			if err != nil {
+				return fmt.Errorf("couldn't read documents of collection %q: %w", k, err)
+			}
 			persistenceDB.Collections[k] = &persistenceCollection{
 				Name:      v.Name,
 				Metadata:  v.metadata,
-				Documents: v.documents,
+				Documents: docs,
 			}
 		}
 	}
@@ -473,10 +720,17 @@ func (db *DB) ExportToWriter(writer io.Writer, compress bool, encryptionKey stri
 
 	for k, v := range db.collections {
 		if len(collections) == 0 || slices.Contains(collections, k) {
+			// Cold documents are exported including their embeddings
+			v.documentsLock.RLock()
+			docs, err := v.allDocuments()
+			v.documentsLock.RUnlock()
+			if err != nil {
+				return fmt.Errorf("couldn't read documents of collection %q: %w", k, err)
+			}
 			persistenceDB.Collections[k] = &persistenceCollection{
 				Name:      v.Name,
 				Metadata:  v.metadata,
-				Documents: v.documents,
+				Documents: docs,
 			}
 		}
 	}
@@ -490,26 +744,57 @@ func (db *DB) ExportToWriter(writer io.Writer, compress bool, encryptionKey stri
 }
 
 // CreateCollection creates a new collection with the given name and metadata.
+// If a collection with the name already exists, it returns [ErrCollectionAlreadyExists].
+// Use [DB.UpsertCollection] to overwrite existing collections instead, or
+// [DB.GetOrCreateCollection] to get the existing one.
 //
 //   - name: The name of the collection to create.
 //   - metadata: Optional metadata to associate with the collection.
 //   - embeddingFunc: Optional function to use to embed documents.
 //     Uses the default embedding function if not provided.
-func (db *DB) CreateCollection(name string, metadata map[string]string, embeddingFunc EmbeddingFunc) (*Collection, error) {
+//   - opts: Optional options for the collection, like [WithTemporalDecay].
+func (db *DB) CreateCollection(name string, metadata map[string]string, embeddingFunc EmbeddingFunc, opts ...CollectionOption) (*Collection, error) {
+	return db.createCollection(name, metadata, embeddingFunc, false, opts...)
+}
+
+// UpsertCollection creates a new collection with the given name and metadata.
+// Unlike [DB.CreateCollection], it overwrites an existing collection with the
+// same name.
+//
+//   - name: The name of the collection to create.
+//   - metadata: Optional metadata to associate with the collection.
+//   - embeddingFunc: Optional function to use to embed documents.
+//     Uses the default embedding function if not provided.
+//   - opts: Optional options for the collection, like [WithTemporalDecay].
+func (db *DB) UpsertCollection(name string, metadata map[string]string, embeddingFunc EmbeddingFunc, opts ...CollectionOption) (*Collection, error) {
+	return db.createCollection(name, metadata, embeddingFunc, true, opts...)
+}
+
+func (db *DB) createCollection(name string, metadata map[string]string, embeddingFunc EmbeddingFunc, overwrite bool, opts ...CollectionOption) (*Collection, error) {
 	if name == "" {
 		return nil, errors.New("collection name is empty")
 	}
 	if embeddingFunc == nil {
 		embeddingFunc = NewEmbeddingFuncDefault()
 	}
-	collection, err := newCollection(name, metadata, embeddingFunc, db.persistDirectory, db.compress)
+
+	// The lock must be held across the existence check and the insert, and also
+	// while creating the collection, because that writes its metadata to disk.
+	db.collectionsLock.Lock()
+	defer db.collectionsLock.Unlock()
+
+	if _, ok := db.collections[name]; ok && !overwrite {
+		return nil, ErrCollectionAlreadyExists
+	}
+
+	collection, err := newCollection(name, metadata, embeddingFunc, db.collectionParentDir(name), db.compress, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("couldn't create collection: %w", err)
 	}
+	collection.checksum = db.checksum
 
-	db.collectionsLock.Lock()
-	defer db.collectionsLock.Unlock()
 	db.collections[name] = collection
+	db.notifyCollectionCreated(name, metadata)
 	return collection, nil
 }
 
@@ -566,13 +851,18 @@ func (db *DB) GetCollection(name string, embeddingFunc EmbeddingFunc) *Collectio
 //   - metadata: Optional metadata to associate with the collection.
 //   - embeddingFunc: Optional function to use to embed documents.
 //     Uses the default embedding function if not provided.
-func (db *DB) GetOrCreateCollection(name string, metadata map[string]string, embeddingFunc EmbeddingFunc) (*Collection, error) {
+//   - opts: Optional options for the collection, like [WithTemporalDecay].
+//     They're only applied when the collection is created.
+func (db *DB) GetOrCreateCollection(name string, metadata map[string]string, embeddingFunc EmbeddingFunc, opts ...CollectionOption) (*Collection, error) {
 	// No need to lock here, because the methods we call do that.
 	collection := db.GetCollection(name, embeddingFunc)
 	if collection == nil {
 		var err error
-		collection, err = db.CreateCollection(name, metadata, embeddingFunc)
-		if err != nil {
+		collection, err = db.CreateCollection(name, metadata, embeddingFunc, opts...)
+		if errors.Is(err, ErrCollectionAlreadyExists) {
+			// It was created concurrently after our check
+			collection = db.GetCollection(name, embeddingFunc)
+		} else if err != nil {
 			return nil, fmt.Errorf("couldn't create collection: %w", err)
 		}
 	}
@@ -582,6 +872,8 @@ func (db *DB) GetOrCreateCollection(name string, metadata map[string]string, emb
 // DeleteCollection deletes the collection with the given name.
 // If the collection doesn't exist, this is a no-op.
 // If the DB is persistent, it also removes the collection's directory.
+// It waits for running operations on the collection to finish, and afterwards
+// writes to the collection fail with [ErrCollectionClosed].
 // You shouldn't hold any references to the collection after calling this method.
 func (db *DB) DeleteCollection(name string) error {
 	db.collectionsLock.Lock()
@@ -592,26 +884,98 @@ func (db *DB) DeleteCollection(name string) error {
 		return nil
 	}
 
+	// Wait for running operations and reject further writes, which would
+	// otherwise recreate the files we're about to remove.
+	col.close()
+	col.discardAutoSave()
+
 	if db.persistDirectory != "" {
 		collectionPath := col.persistDirectory
 		err := os.RemoveAll(collectionPath)
 		if err != nil {
 			return fmt.Errorf("couldn't delete collection directory: %w", err)
 		}
+		if col.walPath != "" {
+			err = removeFile(col.walPath)
+			if err != nil {
+				return fmt.Errorf("couldn't delete collection WAL file: %w", err)
+			}
+		}
 	}
 
 	delete(db.collections, name)
+	db.notifyCollectionDeleted(name)
 	return nil
 }
 
-// Reset removes all collections from the DB.
+// RenameCollection renames the collection. If the DB is persistent, the
+// collection's directory is renamed as well, and so is its write-ahead log if
+// it's in the default location. Existing references to the collection keep
+// working. The method must not be called concurrently with adding or deleting
+// documents of the collection.
+//
+//   - oldName: The current name of the collection.
+//   - newName: The new name. There must not be a collection with that name yet.
+func (db *DB) RenameCollection(oldName, newName string) error {
+	if newName == "" {
+		return errors.New("new collection name is empty")
+	}
+
+	db.collectionsLock.Lock()
+	defer db.collectionsLock.Unlock()
+
+	c, ok := db.collections[oldName]
+	if !ok {
+		return fmt.Errorf("collection '%s' not found", oldName)
+	}
+	if oldName == newName {
+		return nil
+	}
+	if _, ok := db.collections[newName]; ok {
+		return ErrCollectionAlreadyExists
+	}
+
+	if db.persistDirectory != "" {
+		err := c.rename(newName, db.collectionParentDir(newName))
+		if err != nil {
+			return err
+		}
+	} else {
+		c.Name = newName
+	}
+
+	delete(db.collections, oldName)
+	db.collections[newName] = c
+	db.notifyCollectionRenamed(oldName, newName)
+	return nil
+}
+
+// Reset removes all collections and namespaces from the DB.
 // If the DB is persistent, it also removes all contents of the DB directory.
+// It waits for running operations on the collections to finish, and afterwards
+// writes to the old collections fail with [ErrCollectionClosed].
 // You shouldn't hold any references to old collections after calling this method.
 func (db *DB) Reset() error {
 	db.collectionsLock.Lock()
 	defer db.collectionsLock.Unlock()
 
+	// Wait for running operations and reject further writes, which would
+	// otherwise recreate the files we're about to remove.
+	for _, col := range db.collections {
+		col.close()
+		col.discardAutoSave()
+	}
+
 	if db.persistDirectory != "" {
+		// WAL files might be located outside of the persistence directory
+		for _, col := range db.collections {
+			if col.walPath != "" {
+				err := removeFile(col.walPath)
+				if err != nil {
+					return fmt.Errorf("couldn't delete collection WAL file: %w", err)
+				}
+			}
+		}
 		err := os.RemoveAll(db.persistDirectory)
 		if err != nil {
 			return fmt.Errorf("couldn't delete persistence directory: %w", err)
@@ -623,7 +987,84 @@ func (db *DB) Reset() error {
 		}
 	}
 
-	// Just assign a new map, the GC will take care of the rest.
+	names := make([]string, 0, len(db.collections))
+	for name := range db.collections {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	// Just assign new maps, the GC will take care of the rest.
 	db.collections = make(map[string]*Collection)
+	db.namespaces = make(map[string]struct{})
+	for _, name := range names {
+		db.notifyCollectionDeleted(name)
+	}
+	return nil
+}
+
+// MovePersistenceDir moves the persistence directory of the DB to newDir, for
+// example when deploying the application to another location. The directory is
+// renamed, which is atomic on the same filesystem. If the new directory is on a
+// different filesystem, the directory is copied and then deleted.
+//
+// Collections keep working and write to the new directory afterwards.
+// Write-ahead log files that were placed in the persistence directory are moved
+// along. Concurrent writes to the collections are finished before the move, or
+// wait for it.
+//
+//   - newDir: The new path of the persistence directory. It must not exist yet.
+func (db *DB) MovePersistenceDir(newDir string) error {
+	if newDir == "" {
+		return errors.New("new directory is empty")
+	}
+
+	// The checks must happen while holding the lock, otherwise a concurrent
+	// move could change the directory in between.
+	db.collectionsLock.Lock()
+	defer db.collectionsLock.Unlock()
+
+	if db.persistDirectory == "" {
+		return errors.New("DB is not persistent")
+	}
+	if _, err := os.Stat(newDir); err == nil {
+		return fmt.Errorf("new directory already exists: %s", newDir)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("couldn't get info about the new directory: %w", err)
+	}
+
+	// Writes to the collections read their directory while holding the
+	// documents lock, so holding all of them makes the move atomic for them.
+	for _, c := range db.collections {
+		c.documentsLock.Lock()
+		defer c.documentsLock.Unlock()
+		c.walLock.Lock()
+		defer c.walLock.Unlock()
+	}
+
+	oldDir := db.persistDirectory
+	err := moveDir(oldDir, newDir)
+	if err != nil {
+		return fmt.Errorf("couldn't move persistence directory: %w", err)
+	}
+	db.persistDirectory = newDir
+
+	for _, c := range db.collections {
+		// Collections of namespaces are in subdirectories
+		if relPath, err := filepath.Rel(oldDir, c.persistDirectory); err == nil {
+			c.persistDirectory = filepath.Join(newDir, relPath)
+		}
+		// The WAL path is stored in the collection metadata, so it must be
+		// updated there as well.
+		if c.walPath != "" {
+			if relPath, err := filepath.Rel(oldDir, c.walPath); err == nil && !strings.HasPrefix(relPath, "..") {
+				c.walPath = filepath.Join(newDir, relPath)
+				err = c.persistMetadata()
+				if err != nil {
+					return fmt.Errorf("couldn't persist metadata of collection %q: %w", c.Name, err)
+				}
+			}
+		}
+	}
+
 	return nil
 }
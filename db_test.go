@@ -1,13 +1,21 @@
 package chromem
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/binary"
+	"errors"
 	"math/rand"
 	"os"
 	"path/filepath"
 	"reflect"
 	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestNewPersistentDB(t *testing.T) {
@@ -67,6 +75,577 @@ func TestNewPersistentDB_Errors(t *testing.T) {
 	})
 }
 
+func TestNewPersistentDB_ChecksumVerification(t *testing.T) {
+	ctx := context.Background()
+	path, err := os.MkdirTemp(os.TempDir(), "")
+	if err != nil {
+		t.Fatal("couldn't create temp dir:", err)
+	}
+	defer os.RemoveAll(path)
+
+	vectors := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return vectors, nil
+	}
+
+	db, err := NewPersistentDB(path, false, WithChecksumVerification())
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c, err := db.CreateCollection("test", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.AddDocument(ctx, Document{ID: "1", Content: "hello world"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	// The sidecar file must exist
+	docPath := c.getDocPath("1")
+	if _, err := os.Stat(checksumPath(docPath)); err != nil {
+		t.Fatal("expected checksum file to exist, got", err)
+	}
+
+	// Loading the valid DB must work
+	_, err = NewPersistentDB(path, false, WithChecksumVerification())
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	// Corrupt the document file
+	b, err := os.ReadFile(docPath)
+	if err != nil {
+		t.Fatal("couldn't read document file:", err)
+	}
+	b[len(b)/2] ^= 0xff
+	err = os.WriteFile(docPath, b, 0o600)
+	if err != nil {
+		t.Fatal("couldn't write document file:", err)
+	}
+
+	_, err = NewPersistentDB(path, false, WithChecksumVerification())
+	var checksumErr ErrChecksumMismatch
+	if !errors.As(err, &checksumErr) {
+		t.Fatal("expected ErrChecksumMismatch, got", err)
+	}
+	if checksumErr.File != docPath {
+		t.Fatal("expected file", docPath, "got", checksumErr.File)
+	}
+
+	// Deleting the document must delete the checksum file as well
+	b[len(b)/2] ^= 0xff
+	err = os.WriteFile(docPath, b, 0o600)
+	if err != nil {
+		t.Fatal("couldn't write document file:", err)
+	}
+	db, err = NewPersistentDB(path, false, WithChecksumVerification())
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = db.GetCollection("test", embeddingFunc).Delete(ctx, nil, nil, "1")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if _, err := os.Stat(checksumPath(docPath)); !os.IsNotExist(err) {
+		t.Fatal("expected checksum file to not exist, got", err)
+	}
+}
+
+func TestNewPersistentDB_ChecksumVerification_RewriteWithoutChecksum(t *testing.T) {
+	ctx := context.Background()
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return []float32{-0.40824828, 0.40824828, 0.81649655}, nil
+	}
+	path := t.TempDir()
+
+	// Write with checksums
+	db, err := NewPersistentDB(path, false, WithChecksumVerification())
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c, err := db.CreateCollection("test", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.AddDocument(ctx, Document{ID: "1", Content: "hello world"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	// Rewrite without checksums
+	db, err = NewPersistentDB(path, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = db.GetCollection("test", embeddingFunc).AddDocument(ctx, Document{ID: "1", Content: "hello again"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if _, err := os.Stat(checksumPath(c.getDocPath("1"))); !os.IsNotExist(err) {
+		t.Fatal("expected checksum file to not exist, got", err)
+	}
+
+	// Reopening with verification must work
+	db, err = NewPersistentDB(path, false, WithChecksumVerification())
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	doc, err := db.GetCollection("test", embeddingFunc).GetByID(ctx, "1")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if doc.Content != "hello again" {
+		t.Fatal("expected content 'hello again', got", doc.Content)
+	}
+}
+
+func TestNewPersistentDB_LoadErrorHandler(t *testing.T) {
+	ctx := context.Background()
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return []float32{-0.40824828, 0.40824828, 0.81649655}, nil
+	}
+	path := t.TempDir()
+
+	db, err := NewPersistentDB(path, false, WithChecksumVerification())
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c, err := db.CreateCollection("test", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.AddDocuments(ctx, []Document{{ID: "1", Content: "hello world"}, {ID: "2", Content: "hallo welt"}}, 1)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c2, err := db.CreateCollection("unreadable", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	// Corrupt a document file and the metadata file of the other collection
+	docPath := c.getDocPath("1")
+	b, err := os.ReadFile(docPath)
+	if err != nil {
+		t.Fatal("couldn't read document file:", err)
+	}
+	b[len(b)/2] ^= 0xff
+	err = os.WriteFile(docPath, b, 0o600)
+	if err != nil {
+		t.Fatal("couldn't write document file:", err)
+	}
+	err = os.WriteFile(filepath.Join(c2.persistDirectory, metadataFileName+".gob"), []byte("invalid"), 0o600)
+	if err != nil {
+		t.Fatal("couldn't write metadata file:", err)
+	}
+
+	// Without a handler, loading fails
+	_, err = NewPersistentDB(path, false, WithChecksumVerification())
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	// With a handler, the broken parts are skipped
+	var loadErrs []LoadError
+	db, err = NewPersistentDB(path, false, WithChecksumVerification(), WithLoadErrorHandler(func(err LoadError) {
+		loadErrs = append(loadErrs, err)
+	}))
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if len(db.ListCollections()) != 1 {
+		t.Fatal("expected 1 collection, got", len(db.ListCollections()))
+	}
+	c = db.GetCollection("test", embeddingFunc)
+	if c.Count() != 1 {
+		t.Fatal("expected 1 document, got", c.Count())
+	}
+	if _, err := c.GetByID(ctx, "2"); err != nil {
+		t.Fatal("expected document 2 to be loaded, got", err)
+	}
+	slices.SortFunc(loadErrs, func(a, b LoadError) int {
+		return strings.Compare(a.File, b.File)
+	})
+	if len(loadErrs) != 2 {
+		t.Fatal("expected 2 load errors, got", loadErrs)
+	}
+	if loadErrs[0].File != "" || loadErrs[0].CollectionPath != c2.persistDirectory {
+		t.Fatal("expected error of unreadable collection, got", loadErrs[0])
+	}
+	if loadErrs[1].File != docPath || loadErrs[1].Collection != "test" || !errors.As(loadErrs[1], &ErrChecksumMismatch{}) {
+		t.Fatal("expected checksum error of document 1, got", loadErrs[1])
+	}
+}
+
+func TestNewPersistentDB_MetadataCompression(t *testing.T) {
+	ctx := context.Background()
+	vectors := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return vectors, nil
+	}
+	abstract := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 50*1024/45)
+	metadata := map[string]string{"abstract": abstract, "short": "foo"}
+
+	// Returns the doc file size
+	addDoc := func(t *testing.T, path string, opts ...CollectionOption) int64 {
+		db, err := NewPersistentDB(path, false)
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		c, err := db.CreateCollection("test", nil, embeddingFunc, opts...)
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		err = c.AddDocument(ctx, Document{ID: "1", Metadata: metadata, Content: "hello world"})
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		// The in-memory document must have the uncompressed values
+		doc, err := c.GetByID(ctx, "1")
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		if !reflect.DeepEqual(metadata, doc.Metadata) {
+			t.Fatal("expected in-memory metadata to be unchanged")
+		}
+		fi, err := os.Stat(c.getDocPath("1"))
+		if err != nil {
+			t.Fatal("couldn't get document file info:", err)
+		}
+		return fi.Size()
+	}
+
+	pathUncompressed := t.TempDir()
+	sizeUncompressed := addDoc(t, pathUncompressed)
+	pathCompressed := t.TempDir()
+	sizeCompressed := addDoc(t, pathCompressed, WithMetadataCompression())
+	if sizeCompressed >= sizeUncompressed {
+		t.Fatalf("expected compressed file (%d bytes) to be smaller than uncompressed file (%d bytes)", sizeCompressed, sizeUncompressed)
+	}
+
+	// Round trip
+	db, err := NewPersistentDB(pathCompressed, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	doc, err := db.GetCollection("test", embeddingFunc).GetByID(ctx, "1")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if !reflect.DeepEqual(metadata, doc.Metadata) {
+		t.Fatal("expected round-tripped metadata to be identical")
+	}
+}
+
+func TestNewPersistentDB_MetadataCompression_GzipValue(t *testing.T) {
+	ctx := context.Background()
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return []float32{-0.40824828, 0.40824828, 0.81649655}, nil
+	}
+
+	// User metadata that is gzip data itself must not be decompressed on reload,
+	// neither without nor with metadata compression.
+	buf := &bytes.Buffer{}
+	gzw := gzip.NewWriter(buf)
+	_, err := gzw.Write([]byte("hello world"))
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = gzw.Close()
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	metadata := map[string]string{
+		"gzip":     buf.String(),
+		"abstract": strings.Repeat("a", 2*metadataCompressionThreshold),
+	}
+
+	for _, compress := range []bool{false, true} {
+		t.Run(strconv.FormatBool(compress), func(t *testing.T) {
+			var opts []CollectionOption
+			if compress {
+				opts = append(opts, WithMetadataCompression())
+			}
+			path := t.TempDir()
+			db, err := NewPersistentDB(path, false)
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+			c, err := db.CreateCollection("test", nil, embeddingFunc, opts...)
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+			err = c.AddDocument(ctx, Document{ID: "1", Metadata: metadata, Content: "hello world"})
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+
+			db, err = NewPersistentDB(path, false)
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+			doc, err := db.GetCollection("test", embeddingFunc).GetByID(ctx, "1")
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+			if !reflect.DeepEqual(metadata, doc.Metadata) {
+				t.Fatal("expected round-tripped metadata to be identical")
+			}
+		})
+	}
+}
+
+func TestNewPersistentDB_WAL(t *testing.T) {
+	ctx := context.Background()
+	vectors := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return vectors, nil
+	}
+
+	path := t.TempDir()
+	db, err := NewPersistentDB(path, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c, err := db.CreateCollection("test", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.EnableWAL("")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	walPath := c.persistDirectory + walFileExt
+	if c.walPath != walPath {
+		t.Fatal("expected WAL path", walPath, "got", c.walPath)
+	}
+	err = c.AddDocument(ctx, Document{ID: "1", Content: "hello world"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	// Checkpoint writes the main files and truncates the WAL
+	err = c.CheckpointWAL()
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	fi, err := os.Stat(walPath)
+	if err != nil {
+		t.Fatal("couldn't get WAL file info:", err)
+	}
+	if fi.Size() != 0 {
+		t.Fatal("expected WAL file to be empty, got size", fi.Size())
+	}
+
+	// Simulate a crash right after writing to the WAL, before the main files
+	// were updated.
+	err = c.appendWAL(walEntry{Op: walOpAdd, Document: &Document{ID: "2", Embedding: vectors, Content: "hallo welt"}})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.appendWAL(walEntry{Op: walOpDelete, ID: "1"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	// And a torn record at the end, from a crash during the append
+	f, err := os.OpenFile(walPath, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	_, err = f.Write([]byte{0, 0, 1, 0, 42})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	f.Close()
+	if _, err := os.Stat(c.getDocPath("2")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("expected document file to not exist yet, got", err)
+	}
+
+	// Loading the DB replays the WAL
+	db, err = NewPersistentDB(path, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c = db.GetCollection("test", embeddingFunc)
+	if c.Count() != 1 {
+		t.Fatal("expected 1 document, got", c.Count())
+	}
+	doc, err := c.GetByID(ctx, "2")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if doc.Content != "hallo welt" {
+		t.Fatal("expected content \"hallo welt\", got", doc.Content)
+	}
+	if c.walPath != walPath {
+		t.Fatal("expected WAL path to be restored, got", c.walPath)
+	}
+	// The main files were updated and the WAL truncated
+	if _, err := os.Stat(c.getDocPath("2")); err != nil {
+		t.Fatal("expected document file to exist, got", err)
+	}
+	if _, err := os.Stat(c.getDocPath("1")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("expected deleted document file to not exist, got", err)
+	}
+	fi, err = os.Stat(walPath)
+	if err != nil {
+		t.Fatal("couldn't get WAL file info:", err)
+	}
+	if fi.Size() != 0 {
+		t.Fatal("expected WAL file to be empty, got size", fi.Size())
+	}
+
+	// A document that can't be logged isn't added
+	c.walPath = t.TempDir() // Directories can't be opened for writing
+	err = c.AddDocument(ctx, Document{ID: "3", Content: "bonjour le monde"})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if _, err := c.GetByID(ctx, "3"); err == nil {
+		t.Fatal("expected document to not be added")
+	}
+}
+
+func TestNewPersistentDB_ReadOnlyLoad(t *testing.T) {
+	ctx := context.Background()
+	vectors := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+
+	// A missing directory isn't created
+	path := filepath.Join(t.TempDir(), "db")
+	_, err := NewPersistentDB(path, false, WithReadOnlyLoad())
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("expected directory to not exist, got", err)
+	}
+
+	db, err := NewPersistentDB(path, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c, err := db.CreateCollection("test", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.EnableWAL("")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.AddDocument(ctx, Document{ID: "1", Embedding: vectors, Content: "hello world"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	// Simulate a crash right after writing to the WAL
+	err = c.appendWAL(walEntry{Op: walOpAdd, Document: &Document{ID: "2", Embedding: vectors, Content: "hallo welt"}})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	walPath := c.walPath
+	wal, err := os.ReadFile(walPath)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	// The WAL isn't replayed
+	db, err = NewPersistentDB(path, false, WithReadOnlyLoad())
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c = db.GetCollection("test", nil)
+	if c.Count() != 1 {
+		t.Fatal("expected 1 document, got", c.Count())
+	}
+	b, err := os.ReadFile(walPath)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if !bytes.Equal(wal, b) {
+		t.Fatal("expected WAL to be unchanged")
+	}
+	if _, err := os.Stat(c.getDocPath("2")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("expected document file to not exist, got", err)
+	}
+}
+
+func TestNewPersistentDB_WAL_CorruptedRecord(t *testing.T) {
+	ctx := context.Background()
+	vectors := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return vectors, nil
+	}
+
+	tt := []struct {
+		name    string
+		corrupt func(record []byte)
+	}{
+		{"Checksum mismatch", func(record []byte) { record[len(record)-1] ^= 0xff }},
+		{"Huge length", func(record []byte) { binary.BigEndian.PutUint32(record, 0xffffffff) }},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			path := t.TempDir()
+			db, err := NewPersistentDB(path, false)
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+			c, err := db.CreateCollection("test", nil, embeddingFunc)
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+			err = c.EnableWAL("")
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+			err = c.appendWAL(walEntry{Op: walOpAdd, Document: &Document{ID: "1", Embedding: vectors, Content: "hello world"}})
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+			fi, err := os.Stat(c.walPath)
+			if err != nil {
+				t.Fatal("couldn't get WAL file info:", err)
+			}
+			firstRecordSize := fi.Size()
+			err = c.appendWAL(walEntry{Op: walOpAdd, Document: &Document{ID: "2", Embedding: vectors, Content: "hallo welt"}})
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+
+			// Corrupt the second record
+			b, err := os.ReadFile(c.walPath)
+			if err != nil {
+				t.Fatal("couldn't read WAL file:", err)
+			}
+			tc.corrupt(b[firstRecordSize:])
+			err = os.WriteFile(c.walPath, b, 0o600)
+			if err != nil {
+				t.Fatal("couldn't write WAL file:", err)
+			}
+
+			// The corrupted record is treated as the end of the log
+			db, err = NewPersistentDB(path, false)
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+			c = db.GetCollection("test", embeddingFunc)
+			if c.Count() != 1 {
+				t.Fatal("expected 1 document, got", c.Count())
+			}
+			if _, err := c.GetByID(ctx, "1"); err != nil {
+				t.Fatal("expected document 1 to exist, got", err)
+			}
+			fi, err = os.Stat(c.walPath)
+			if err != nil {
+				t.Fatal("couldn't get WAL file info:", err)
+			}
+			if fi.Size() != 0 {
+				t.Fatal("expected WAL file to be empty, got size", fi.Size())
+			}
+		})
+	}
+}
+
 func TestDB_ImportExport(t *testing.T) {
 	r := rand.New(rand.NewSource(rand.Int63()))
 	randString := randomString(r, 10)
@@ -332,6 +911,71 @@ func TestDB_CreateCollection(t *testing.T) {
 	})
 }
 
+func TestDB_CreateCollection_Concurrent(t *testing.T) {
+	vectors := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return vectors, nil
+	}
+
+	db := NewDB()
+	const n = 50
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	cols := make([]*Collection, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cols[i], errs[i] = db.CreateCollection("test", nil, embeddingFunc)
+		}(i)
+	}
+	wg.Wait()
+
+	var winner *Collection
+	for i, err := range errs {
+		if err == nil {
+			if winner != nil {
+				t.Fatal("expected exactly one successful call, got more")
+			}
+			winner = cols[i]
+		} else if !errors.Is(err, ErrCollectionAlreadyExists) {
+			t.Fatal("expected ErrCollectionAlreadyExists, got", err)
+		}
+	}
+	if winner == nil {
+		t.Fatal("expected exactly one successful call, got none")
+	}
+	if db.GetCollection("test", nil) != winner {
+		t.Fatal("expected the successfully created collection to be in the DB")
+	}
+}
+
+func TestDB_UpsertCollection(t *testing.T) {
+	vectors := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return vectors, nil
+	}
+
+	db := NewDB()
+	c1, err := db.CreateCollection("test", map[string]string{"version": "1"}, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c2, err := db.UpsertCollection("test", map[string]string{"version": "2"}, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if c1 == c2 {
+		t.Fatal("expected a new collection")
+	}
+	if db.GetCollection("test", nil) != c2 {
+		t.Fatal("expected the upserted collection to be in the DB")
+	}
+	if c2.metadata["version"] != "2" {
+		t.Fatal("expected metadata version 2, got", c2.metadata["version"])
+	}
+}
+
 func TestDB_ListCollections(t *testing.T) {
 	// Values in the collection
 	name := "test"
@@ -526,6 +1170,91 @@ func TestDB_DeleteCollection(t *testing.T) {
 	}
 }
 
+func TestDB_RenameCollection(t *testing.T) {
+	ctx := context.Background()
+	vectors := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return vectors, nil
+	}
+
+	tempDir := t.TempDir()
+	db, err := NewPersistentDB(tempDir, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c, err := db.CreateCollection("old", map[string]string{"foo": "bar"}, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.EnableWAL("")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.AddDocument(ctx, Document{ID: "1", Content: "foo"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	_, err = db.CreateCollection("other", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	// Errors
+	err = db.RenameCollection("missing", "new")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	err = db.RenameCollection("old", "other")
+	if !errors.Is(err, ErrCollectionAlreadyExists) {
+		t.Fatal("expected ErrCollectionAlreadyExists, got", err)
+	}
+
+	err = db.RenameCollection("old", "new")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if db.GetCollection("old", nil) != nil {
+		t.Fatal("expected old name to not exist anymore")
+	}
+	if db.GetCollection("new", nil) != c {
+		t.Fatal("expected collection under the new name")
+	}
+	if c.Name != "new" {
+		t.Fatal("expected name \"new\", got", c.Name)
+	}
+
+	// The existing reference keeps working
+	err = c.AddDocument(ctx, Document{ID: "2", Content: "bar"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	// The renamed collection is loaded from disk
+	db2, err := NewPersistentDB(tempDir, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if db2.GetCollection("old", nil) != nil {
+		t.Fatal("expected old name to not exist anymore")
+	}
+	c2 := db2.GetCollection("new", nil)
+	if c2 == nil {
+		t.Fatal("expected collection, got nil")
+	}
+	if c2.Count() != 2 {
+		t.Fatal("expected 2 documents, got", c2.Count())
+	}
+	if c2.metadata["foo"] != "bar" {
+		t.Fatal("expected metadata foo=bar, got", c2.metadata)
+	}
+	if c2.walPath != c2.persistDirectory+walFileExt {
+		t.Fatal("expected WAL next to the collection directory, got", c2.walPath)
+	}
+	if _, err := os.Stat(c2.walPath); err != nil {
+		t.Fatal("expected WAL file to exist, got", err)
+	}
+}
+
 func TestDB_Reset(t *testing.T) {
 	// Values in the collection
 	name := "test"
@@ -559,3 +1288,242 @@ func TestDB_Reset(t *testing.T) {
 		t.Fatal("expected 0 collections, got", len(db.collections))
 	}
 }
+
+func TestDB_Reset_InFlight(t *testing.T) {
+	ctx := context.Background()
+	vectors := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+	queryStarted := make(chan struct{})
+	releaseQuery := make(chan struct{})
+	embeddingFunc := func(_ context.Context, text string) ([]float32, error) {
+		if text == "query" {
+			close(queryStarted)
+			<-releaseQuery
+		}
+		return vectors, nil
+	}
+
+	tempDir := t.TempDir()
+	db, err := NewPersistentDB(tempDir, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c, err := db.CreateCollection("test", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.AddDocuments(ctx, []Document{
+		{ID: "1", Content: "foo"},
+		{ID: "2", Content: "bar"},
+	}, 1)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	var wg sync.WaitGroup
+	var res []Result
+	var queryErr, resetErr error
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		res, queryErr = c.QueryAll(ctx, "query", nil, nil)
+	}()
+	<-queryStarted
+
+	resetDone := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(resetDone)
+		resetErr = db.Reset()
+	}()
+
+	// Reset must wait for the query
+	select {
+	case <-resetDone:
+		t.Fatal("expected Reset to wait for the in-flight query")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(releaseQuery)
+	wg.Wait()
+
+	if queryErr != nil {
+		t.Fatal("expected no error, got", queryErr)
+	}
+	if len(res) != 2 {
+		t.Fatal("expected 2 results, got", len(res))
+	}
+	if resetErr != nil {
+		t.Fatal("expected no error, got", resetErr)
+	}
+
+	// Writes to the old collection are rejected and don't recreate any files
+	err = c.AddDocument(ctx, Document{ID: "3", Content: "baz"})
+	if !errors.Is(err, ErrCollectionClosed) {
+		t.Fatal("expected ErrCollectionClosed, got", err)
+	}
+	err = c.Delete(ctx, nil, nil, "1")
+	if !errors.Is(err, ErrCollectionClosed) {
+		t.Fatal("expected ErrCollectionClosed, got", err)
+	}
+	dirEntries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if len(dirEntries) != 0 {
+		t.Fatal("expected empty directory, got", len(dirEntries), "entries")
+	}
+
+	// Reads still work on the in-memory data
+	_, err = c.GetByID(ctx, "1")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+}
+
+func TestDB_MovePersistenceDir(t *testing.T) {
+	ctx := context.Background()
+	vectors := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return vectors, nil
+	}
+
+	oldDir := filepath.Join(t.TempDir(), "db")
+	newDir := filepath.Join(t.TempDir(), "moved", "db")
+	err := os.MkdirAll(filepath.Dir(newDir), 0o700)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	db, err := NewPersistentDB(oldDir, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	for _, name := range []string{"a", "b"} {
+		c, err := db.CreateCollection(name, nil, embeddingFunc)
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		err = c.AddDocument(ctx, Document{ID: "1", Content: "hello world"})
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+	}
+	err = db.GetCollection("b", nil).EnableWAL("")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	err = db.MovePersistenceDir(newDir)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if _, err := os.Stat(oldDir); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("expected old directory to be gone, got", err)
+	}
+
+	// Collections write to the new directory
+	c := db.GetCollection("a", nil)
+	err = c.AddDocument(ctx, Document{ID: "2", Content: "hallo welt"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if !strings.HasPrefix(c.getDocPath("2"), newDir) {
+		t.Fatal("expected document path in new directory, got", c.getDocPath("2"))
+	}
+	wantWALPath := filepath.Join(newDir, hash2hex("b")+walFileExt)
+	if walPath := db.GetCollection("b", nil).walPath; walPath != wantWALPath {
+		t.Fatal("expected WAL path", wantWALPath, "got", walPath)
+	}
+	if _, err := os.Stat(oldDir); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("expected old directory to not be recreated, got", err)
+	}
+
+	// A fresh DB loads all collections from the new directory
+	db2, err := NewPersistentDB(newDir, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if len(db2.ListCollections()) != 2 {
+		t.Fatal("expected 2 collections, got", len(db2.ListCollections()))
+	}
+	if db2.GetCollection("a", nil).Count() != 2 {
+		t.Fatal("expected 2 documents, got", db2.GetCollection("a", nil).Count())
+	}
+	if db2.GetCollection("b", nil).walPath != wantWALPath {
+		t.Fatal("expected WAL path", wantWALPath, "got", db2.GetCollection("b", nil).walPath)
+	}
+
+	// The destination must not exist
+	err = db2.MovePersistenceDir(t.TempDir())
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	// Concurrent moves to the same destination must not both succeed
+	concurrentDir := filepath.Join(t.TempDir(), "concurrent")
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			errs <- db2.MovePersistenceDir(concurrentDir)
+		}()
+	}
+	if err1, err2 := <-errs, <-errs; (err1 == nil) == (err2 == nil) {
+		t.Fatal("expected exactly one move to succeed, got", err1, err2)
+	}
+	if db2.persistDirectory != concurrentDir {
+		t.Fatal("expected persistence directory", concurrentDir, "got", db2.persistDirectory)
+	}
+}
+
+func TestDB_MovePersistenceDir_ConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "db")
+	newDir := filepath.Join(t.TempDir(), "moved")
+
+	db, err := NewPersistentDB(path, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c, err := db.CreateCollection("test", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	// Documents that are added during the move must end up in the new directory
+	const n = 200
+	started := make(chan struct{})
+	errs := make(chan error, 1)
+	go func() {
+		for i := 0; i < n; i++ {
+			if i == n/2 {
+				close(started)
+			}
+			err := c.AddDocument(ctx, Document{ID: strconv.Itoa(i), Embedding: []float32{1, 0, 0}})
+			if err != nil {
+				errs <- err
+				return
+			}
+		}
+		errs <- nil
+	}()
+	<-started
+	err = db.MovePersistenceDir(newDir)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = <-errs
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("expected old directory to not exist, got", err)
+	}
+	db, err = NewPersistentDB(newDir, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if count := db.GetCollection("test", nil).Count(); count != n {
+		t.Fatal("expected", n, "documents, got", count)
+	}
+}
@@ -0,0 +1,243 @@
+package chromem
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+)
+
+// persistedDocument is the persisted form of a [Document] in delta-encoded
+// collections and with compressed metadata values. Documents are read from disk
+// into this struct. Its fields must match the ones of Document, plus the ones
+// for the delta encoding and metadata compression, so that all forms can be
+// decoded.
+type persistedDocument struct {
+	ID              string
+	Metadata        map[string]string
+	Embedding       []float32
+	Content         string
+	SparseEmbedding map[uint32]float32
+	Tier            Tier
+
+	// Set instead of Embedding in delta-encoded collections. Each element is the
+	// XOR of the bits of the embedding's and the reference vector's element.
+	EmbeddingDelta []uint32
+	// CRC32 of the reference vector that the embedding was encoded with
+	DeltaReference uint32
+
+	// Keys of the metadata values that are compressed with gzip, see
+	// [WithMetadataCompression]. Only these values are decompressed when reading.
+	CompressedMetadataKeys []string
+}
+
+// EnableDeltaEncoding makes the collection store the embeddings of its documents
+// as difference from a reference vector, which reduces the file sizes when the
+// embeddings are similar, for example for articles on the same topic.
+// The difference is the XOR of the bits of each float32 element. When elements
+// are close to the reference, the XOR has many leading zero bits, which are
+// encoded in fewer bytes. The encoding is lossless.
+//
+// The encoding only affects the document files. In memory, the embeddings are
+// kept as is, so queries work as usual. The reference vector is stored in the
+// collection's metadata file, so later changes of the reference document don't
+// affect the encoding. Calling it again re-encodes the documents with the new
+// reference. This includes cold documents (see [Collection.MoveToColdStorage]),
+// whose embeddings are read from disk for that. Documents with a different
+// dimension than the reference are stored without encoding.
+//
+// Only works for persistent collections. Concurrent writes to the collection
+// wait until the documents are re-encoded.
+//
+//   - referenceDocID: The ID of the document whose embedding is the reference.
+//     If empty, the centroid of all documents' embeddings is used.
+func (c *Collection) EnableDeltaEncoding(referenceDocID string) error {
+	if c.persistDirectory == "" {
+		return errors.New("collection is not persistent")
+	}
+
+	end, err := c.beginOp(true)
+	if err != nil {
+		return err
+	}
+	defer end()
+
+	c.documentsLock.Lock()
+	defer c.documentsLock.Unlock()
+
+	var ref []float32
+	if referenceDocID != "" {
+		doc, ok := c.documents[referenceDocID]
+		if !ok {
+			return fmt.Errorf("reference document with ID '%v' not found", referenceDocID)
+		}
+		ref = make([]float32, len(doc.Embedding))
+		copy(ref, doc.Embedding)
+	} else {
+		ref, err = centroid(c.documents)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The reference is swapped while holding the lock, so that documents that
+	// are added concurrently are encoded with the new one. The previous
+	// reference is kept in the metadata until all documents are re-encoded, so
+	// that documents can be decoded if the process stops in between.
+	c.prevDeltaReference = c.deltaReference
+	c.deltaReference = ref
+	err = c.persistMetadata()
+	if err != nil {
+		return fmt.Errorf("couldn't persist collection metadata: %w", err)
+	}
+	for _, doc := range c.documents {
+		err = c.persistDocument(doc)
+		if err != nil {
+			return err
+		}
+	}
+	for id := range c.cold {
+		doc, err := c.loadColdDocument(id)
+		if err != nil {
+			return err
+		}
+		err = c.persistDocumentToPath(c.getColdDocPath(id), doc)
+		if err != nil {
+			return err
+		}
+	}
+	c.prevDeltaReference = nil
+	err = c.persistMetadata()
+	if err != nil {
+		return fmt.Errorf("couldn't persist collection metadata: %w", err)
+	}
+
+	return nil
+}
+
+// centroid returns the element-wise mean of the documents' embeddings.
+func centroid(docs map[string]*Document) ([]float32, error) {
+	var sum []float64
+	n := 0
+	for _, doc := range docs {
+		if sum == nil {
+			sum = make([]float64, len(doc.Embedding))
+		} else if len(doc.Embedding) != len(sum) {
+			return nil, errors.New("documents have embeddings with different dimensions")
+		}
+		for i, v := range doc.Embedding {
+			sum[i] += float64(v)
+		}
+		n++
+	}
+	if n == 0 {
+		return nil, errors.New("collection is empty")
+	}
+
+	res := make([]float32, len(sum))
+	for i, v := range sum {
+		res[i] = float32(v / float64(n))
+	}
+	return res, nil
+}
+
+// deltaEncode returns the XOR of the bits of the vector's and the reference's
+// elements. Both must have the same length.
+func deltaEncode(v, ref []float32) []uint32 {
+	res := make([]uint32, len(v))
+	for i := range v {
+		res[i] = math.Float32bits(v[i]) ^ math.Float32bits(ref[i])
+	}
+	return res
+}
+
+// deltaDecode reverses [deltaEncode].
+func deltaDecode(delta []uint32, ref []float32) []float32 {
+	res := make([]float32, len(delta))
+	for i := range delta {
+		res[i] = math.Float32frombits(delta[i] ^ math.Float32bits(ref[i]))
+	}
+	return res
+}
+
+// deltaReferenceHash returns the CRC32 of the reference vector, which is stored
+// with each encoded document.
+func deltaReferenceHash(ref []float32) uint32 {
+	b := make([]byte, 4*len(ref))
+	for i, v := range ref {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(v))
+	}
+	return crc32.ChecksumIEEE(b)
+}
+
+// toPersisted returns the persisted form of the document, with the embedding
+// delta-encoded if the collection has a reference vector with the same
+// dimension, and long metadata values compressed if the collection has metadata
+// compression enabled. Otherwise it returns the document itself.
+// The caller must hold the documents lock, as the reference vector can change.
+func (c *Collection) toPersisted(doc *Document) (any, error) {
+	metadata := doc.Metadata
+	var compressedKeys []string
+	if c.compressMetadata {
+		var err error
+		metadata, compressedKeys, err = compressMetadataValues(doc.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't compress metadata of document %q: %w", doc.ID, err)
+		}
+	}
+	ref := c.deltaReference
+	deltaEncoded := len(ref) != 0 && len(doc.Embedding) == len(ref)
+	if !deltaEncoded && len(compressedKeys) == 0 {
+		return doc, nil
+	}
+
+	pd := &persistedDocument{
+		ID:                     doc.ID,
+		Metadata:               metadata,
+		Content:                doc.Content,
+		SparseEmbedding:        doc.SparseEmbedding,
+		Tier:                   doc.Tier,
+		CompressedMetadataKeys: compressedKeys,
+	}
+	if deltaEncoded {
+		pd.EmbeddingDelta = deltaEncode(doc.Embedding, ref)
+		pd.DeltaReference = deltaReferenceHash(ref)
+	} else {
+		pd.Embedding = doc.Embedding
+	}
+	return pd, nil
+}
+
+// toDocument returns the document of the persisted form. Compressed metadata
+// values are decompressed in place. Delta-encoded embeddings are decoded with
+// the collection's reference vector, or with the previous one if the documents
+// were being re-encoded.
+// The caller must hold the documents lock, unless the collection is still being
+// loaded.
+func (c *Collection) toDocument(pd *persistedDocument) (*Document, error) {
+	err := decompressMetadataValues(pd.Metadata, pd.CompressedMetadataKeys)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't decompress metadata of document '%s': %w", pd.ID, err)
+	}
+
+	doc := &Document{
+		ID:              pd.ID,
+		Metadata:        pd.Metadata,
+		Embedding:       pd.Embedding,
+		Content:         pd.Content,
+		SparseEmbedding: pd.SparseEmbedding,
+		Tier:            pd.Tier,
+	}
+	if len(pd.EmbeddingDelta) == 0 {
+		return doc, nil
+	}
+
+	for _, ref := range [][]float32{c.deltaReference, c.prevDeltaReference} {
+		if len(ref) == len(pd.EmbeddingDelta) && deltaReferenceHash(ref) == pd.DeltaReference {
+			doc.Embedding = deltaDecode(pd.EmbeddingDelta, ref)
+			return doc, nil
+		}
+	}
+	return nil, fmt.Errorf("reference vector of delta-encoded document '%s' not found", pd.ID)
+}
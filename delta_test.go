@@ -0,0 +1,260 @@
+package chromem
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPersistedDocument_Fields(t *testing.T) {
+	// All fields of Document must exist in persistedDocument, otherwise they're
+	// lost when loading a persistent DB.
+	docType := reflect.TypeOf(Document{})
+	pdType := reflect.TypeOf(persistedDocument{})
+	for i := 0; i < docType.NumField(); i++ {
+		f := docType.Field(i)
+		pf, ok := pdType.FieldByName(f.Name)
+		if !ok {
+			t.Fatal("expected field", f.Name, "in persistedDocument")
+		}
+		if pf.Type != f.Type {
+			t.Fatal("expected type", f.Type, "of field", f.Name, "got", pf.Type)
+		}
+	}
+}
+
+func TestCollection_EnableDeltaEncoding(t *testing.T) {
+	ctx := context.Background()
+	r := rand.New(rand.NewSource(rand.Int63()))
+
+	// Homogeneous dataset: small variations of the same vector
+	dim := 256
+	base := make([]float32, dim)
+	for i := range base {
+		base[i] = r.Float32()
+	}
+	var docs []Document
+	for i := 0; i < 100; i++ {
+		v := make([]float32, dim)
+		for j := range v {
+			v[j] = base[j] * (1 + (r.Float32()-0.5)/50)
+		}
+		docs = append(docs, Document{ID: strconv.Itoa(i), Embedding: v, Content: "doc " + strconv.Itoa(i)})
+	}
+
+	// docFilesSize returns the total size of the document files.
+	docFilesSize := func(t *testing.T, c *Collection) int64 {
+		t.Helper()
+		var size int64
+		for _, doc := range docs {
+			fi, err := os.Stat(c.getDocPath(doc.ID))
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+			size += fi.Size()
+		}
+		return size
+	}
+
+	// Reference collection without delta encoding
+	refDir := filepath.Join(t.TempDir(), "ref")
+	refDB, err := NewPersistentDB(refDir, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	refCol, err := refDB.CreateCollection("test", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = refCol.AddDocuments(ctx, docs, 1)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	tempDir := filepath.Join(t.TempDir(), "delta")
+	db, err := NewPersistentDB(tempDir, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c, err := db.CreateCollection("test", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.AddDocuments(ctx, docs[:50], 1)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.EnableDeltaEncoding("")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	// Documents added afterwards are encoded as well
+	err = c.AddDocuments(ctx, docs[50:], 1)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	refSize := docFilesSize(t, refCol)
+	size := docFilesSize(t, c)
+	if size >= refSize {
+		t.Fatal("expected delta-encoded files to be smaller than", refSize, "got", size)
+	}
+
+	// checkLoaded loads the DB and compares it with the reference collection.
+	checkLoaded := func(t *testing.T) {
+		t.Helper()
+		db2, err := NewPersistentDB(tempDir, false)
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		c2 := db2.GetCollection("test", nil)
+		if c2.Count() != len(docs) {
+			t.Fatal("expected", len(docs), "documents, got", c2.Count())
+		}
+		for _, doc := range docs {
+			want, err := refCol.GetByID(ctx, doc.ID)
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+			got, err := c2.GetByID(ctx, doc.ID)
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+			if !slices.Equal(want.Embedding, got.Embedding) {
+				t.Fatal("expected embedding", want.Embedding, "got", got.Embedding)
+			}
+			if got.Content != doc.Content {
+				t.Fatal("expected content", doc.Content, "got", got.Content)
+			}
+		}
+
+		// Same query results. All documents are queried, as with a limit,
+		// documents with equal similarity at the boundary can be swapped.
+		query := docs[7].Embedding
+		wantRes, err := refCol.QueryEmbedding(ctx, query, len(docs), nil, nil)
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		res, err := c2.QueryEmbedding(ctx, query, len(docs), nil, nil)
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		// Documents with equal similarity can be in any order
+		want := make(map[string]float32)
+		for _, r := range wantRes {
+			want[r.ID] = r.Similarity
+		}
+		got := make(map[string]float32)
+		for _, r := range res {
+			got[r.ID] = r.Similarity
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Fatal("expected results", want, "got", got)
+		}
+	}
+	checkLoaded(t)
+
+	// Re-encoding with a document as reference
+	err = c.EnableDeltaEncoding("3")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	checkLoaded(t)
+}
+
+func TestCollection_EnableDeltaEncoding_Errors(t *testing.T) {
+	c, err := NewDB().CreateCollection("test", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.EnableDeltaEncoding("")
+	if err == nil {
+		t.Fatal("expected error for non-persistent collection, got nil")
+	}
+
+	db, err := NewPersistentDB(t.TempDir(), false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c, err = db.CreateCollection("test", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.EnableDeltaEncoding("")
+	if err == nil || !strings.Contains(err.Error(), "empty") {
+		t.Fatal("expected error for empty collection, got", err)
+	}
+	err = c.EnableDeltaEncoding("missing")
+	if err == nil {
+		t.Fatal("expected error for missing reference document, got nil")
+	}
+}
+
+func TestCollection_EnableDeltaEncoding_ConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+	tempDir := t.TempDir()
+	db, err := NewPersistentDB(tempDir, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c, err := db.CreateCollection("test", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	embedding := func(i int) []float32 {
+		return normalizeVector([]float32{1, float32(i), 2})
+	}
+	err = c.AddDocument(ctx, Document{ID: "ref", Embedding: []float32{1, 0, 0}})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	// Documents that are added while the reference changes must be decodable
+	// after loading
+	const n = 200
+	started := make(chan struct{})
+	errs := make(chan error, 1)
+	go func() {
+		for i := 0; i < n; i++ {
+			if i == n/2 {
+				close(started)
+			}
+			err := c.AddDocument(ctx, Document{ID: strconv.Itoa(i), Embedding: embedding(i)})
+			if err != nil {
+				errs <- err
+				return
+			}
+		}
+		errs <- nil
+	}()
+	<-started
+	err = c.EnableDeltaEncoding("ref")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = <-errs
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	db, err = NewPersistentDB(tempDir, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c = db.GetCollection("test", nil)
+	for i := 0; i < n; i++ {
+		doc, err := c.GetByID(ctx, strconv.Itoa(i))
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		if !slices.Equal(embedding(i), doc.Embedding) {
+			t.Fatal("expected embedding", embedding(i), "got", doc.Embedding)
+		}
+	}
+}
@@ -12,6 +12,17 @@ type Document struct {
 	Embedding []float32
 	Content   string
 
+	// SparseEmbedding is an optional sparse vector representation of the
+	// document, like the output of SPLADE or BM25, mapping dimension to value.
+	// See [Collection.AddHybrid] and [Collection.QueryHybrid].
+	SparseEmbedding map[uint32]float32
+
+	// Tier is the storage tier of the document. It defaults to [TierHot].
+	// Adding a document with [TierCold] adds it directly to the cold tier, which
+	// only works for persistent collections. Existing documents can be moved
+	// there with [Collection.MoveToColdStorage].
+	Tier Tier
+
 	// ⚠️ When adding unexported fields here, consider adding a persistence struct
 	// version of this in [DB.Export] and [DB.Import].
 }
@@ -51,3 +62,17 @@ func NewDocument(ctx context.Context, id string, metadata map[string]string, emb
 		Content:   content,
 	}, nil
 }
+
+// HybridDocument is a document with both a dense and a sparse vector
+// representation. See [Collection.AddHybridDocument].
+type HybridDocument struct {
+	ID       string
+	Metadata map[string]string
+	Content  string
+
+	// DenseVector is the regular embedding of the document. If it's empty, it
+	// will be created using the collection's embedding function.
+	DenseVector []float32
+	// SparseVector maps dimension to value, like the output of SPLADE or BM25.
+	SparseVector map[uint32]float32
+}
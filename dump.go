@@ -0,0 +1,117 @@
+package chromem
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// dumpContentLen is the maximum number of characters of the content that's
+// written by [Collection.DumpText].
+const dumpContentLen = 80
+
+// dumpEscaper escapes characters that would break the line based dump format.
+var dumpEscaper = strings.NewReplacer("\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// DumpText writes a human-readable dump of the collection's documents to w,
+// for example to include it in a bug report. It's intended for debugging only,
+// and the output format is not stable.
+//
+// Documents are sorted by ID, and each is written as a single line with the
+// tab-separated ID, embedding norm, embedding dimensions, the first 80
+// characters of the content and the metadata as sorted key=value pairs.
+func (c *Collection) DumpText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	err := c.dumpText(bw)
+	if err != nil {
+		return err
+	}
+	err = bw.Flush()
+	if err != nil {
+		return fmt.Errorf("couldn't write dump: %w", err)
+	}
+	return nil
+}
+
+func (c *Collection) dumpText(w io.Writer) error {
+	c.documentsLock.RLock()
+	docs := make([]*Document, 0, len(c.documents)+len(c.cold))
+	for _, doc := range c.documents {
+		docs = append(docs, doc)
+	}
+	// Cold documents don't have their embeddings in memory
+	for _, doc := range c.cold {
+		docs = append(docs, doc)
+	}
+	c.documentsLock.RUnlock()
+	// The documents are never modified, only replaced, so we can access them
+	// without the lock.
+	slices.SortFunc(docs, func(a, b *Document) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	for _, doc := range docs {
+		content := []rune(doc.Content)
+		if len(content) > dumpContentLen {
+			content = content[:dumpContentLen]
+		}
+		_, err := fmt.Fprintf(w, "%s\t%.4f\t%d\t%s", dumpEscaper.Replace(doc.ID), vectorNorm(doc.Embedding), len(doc.Embedding), dumpEscaper.Replace(string(content)))
+		if err != nil {
+			return fmt.Errorf("couldn't write dump: %w", err)
+		}
+
+		keys := make([]string, 0, len(doc.Metadata))
+		for k := range doc.Metadata {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			_, err = fmt.Fprintf(w, "\t%s=%s", dumpEscaper.Replace(k), dumpEscaper.Replace(doc.Metadata[k]))
+			if err != nil {
+				return fmt.Errorf("couldn't write dump: %w", err)
+			}
+		}
+
+		_, err = io.WriteString(w, "\n")
+		if err != nil {
+			return fmt.Errorf("couldn't write dump: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// DumpText writes a human-readable dump of all collections to w, sorted by
+// name. Each collection starts with a "# collection: {name}" line, followed by
+// its documents in the format of [Collection.DumpText]. It's intended for
+// debugging only, and the output format is not stable.
+func (db *DB) DumpText(w io.Writer) error {
+	db.collectionsLock.RLock()
+	collections := make([]*Collection, 0, len(db.collections))
+	for _, c := range db.collections {
+		collections = append(collections, c)
+	}
+	db.collectionsLock.RUnlock()
+	slices.SortFunc(collections, func(a, b *Collection) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	bw := bufio.NewWriter(w)
+	for _, c := range collections {
+		_, err := fmt.Fprintf(bw, "# collection: %s\n", dumpEscaper.Replace(c.Name))
+		if err != nil {
+			return fmt.Errorf("couldn't write dump: %w", err)
+		}
+		err = c.dumpText(bw)
+		if err != nil {
+			return fmt.Errorf("couldn't dump collection %q: %w", c.Name, err)
+		}
+	}
+	err := bw.Flush()
+	if err != nil {
+		return fmt.Errorf("couldn't write dump: %w", err)
+	}
+	return nil
+}
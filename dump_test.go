@@ -0,0 +1,80 @@
+package chromem
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCollection_DumpText(t *testing.T) {
+	ctx := context.Background()
+	vectors := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return vectors, nil
+	}
+
+	db := NewDB()
+	c, err := db.CreateCollection("test", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	longContent := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 3)
+	err = c.AddDocuments(ctx, []Document{
+		{ID: "1", Metadata: map[string]string{"foo": "bar", "a": "b"}, Content: longContent},
+		{ID: "2", Content: "multi\nline"},
+	}, 1)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	buf := &bytes.Buffer{}
+	err = c.DumpText(buf)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), buf.String())
+	}
+	want := "1\t1.0000\t3\t" + longContent[:80] + "\ta=b\tfoo=bar"
+	if lines[0] != want {
+		t.Fatalf("expected line %q, got %q", want, lines[0])
+	}
+	want = "2\t1.0000\t3\tmulti\\nline"
+	if lines[1] != want {
+		t.Fatalf("expected line %q, got %q", want, lines[1])
+	}
+}
+
+func TestDB_DumpText(t *testing.T) {
+	ctx := context.Background()
+	vectors := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return vectors, nil
+	}
+
+	db := NewDB()
+	for _, name := range []string{"b", "a"} {
+		c, err := db.CreateCollection(name, nil, embeddingFunc)
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		err = c.AddDocument(ctx, Document{ID: name + "-doc", Content: "hello " + name})
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	err := db.DumpText(buf)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	want := "# collection: a\na-doc\t1.0000\t3\thello a\n# collection: b\nb-doc\t1.0000\t3\thello b\n"
+	if buf.String() != want {
+		t.Fatalf("expected dump %q, got %q", want, buf.String())
+	}
+}
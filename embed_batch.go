@@ -0,0 +1,138 @@
+package chromem
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxBatchSizeOpenAI is the maximum number of inputs per request of OpenAI's
+// embeddings endpoint.
+const maxBatchSizeOpenAI = 2048
+
+// BatchEmbeddingFunc is a function that creates embeddings for multiple texts
+// at once, which requires fewer API calls than an [EmbeddingFunc] called for
+// each text. The returned slice has the same length and order as the texts.
+//
+// If embedding some of the texts fails, the function can return the embeddings
+// of the others together with the error, in which case the embeddings of the
+// failed texts are nil.
+type BatchEmbeddingFunc func(ctx context.Context, texts []string) ([][]float32, error)
+
+type openAIBatchResponse struct {
+	Data []struct {
+		Embedding []float32 `json:"embedding"`
+		Index     int       `json:"index"`
+	} `json:"data"`
+}
+
+// NewBatchedEmbeddingFuncOpenAI returns a function that creates embeddings for
+// multiple texts using the OpenAI API. The texts are split into chunks of
+// maxBatchSize, with one API call per chunk.
+//
+// The chunks are sent one after another. If a chunk fails, the remaining chunks
+// are still sent, and the function returns the embeddings of the successful
+// chunks together with the errors of all failed chunks. The embeddings of the
+// texts in failed chunks are nil.
+//
+//   - model: The model name, like one of the [EmbeddingModelOpenAI] constants.
+//   - maxBatchSize: The maximum number of texts per API call. If it's <= 0 or
+//     greater than OpenAI's limit of 2048, the limit is used.
+func NewBatchedEmbeddingFuncOpenAI(apiKey string, model string, maxBatchSize int) BatchEmbeddingFunc {
+	return newBatchedEmbeddingFuncOpenAI(BaseURLOpenAI, apiKey, model, maxBatchSize)
+}
+
+func newBatchedEmbeddingFuncOpenAI(baseURL, apiKey, model string, maxBatchSize int) BatchEmbeddingFunc {
+	if maxBatchSize <= 0 || maxBatchSize > maxBatchSizeOpenAI {
+		maxBatchSize = maxBatchSizeOpenAI
+	}
+
+	// We don't set a default timeout here, although it's usually a good idea.
+	// In our case though, the library user can set the timeout on the context,
+	// and it might have to be a long timeout, depending on the text length.
+	client := &http.Client{}
+
+	embedChunk := func(ctx context.Context, texts []string) ([][]float32, error) {
+		// Prepare the request body.
+		reqBody, err := json.Marshal(map[string]any{
+			"input": texts,
+			"model": model,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("couldn't marshal request body: %w", err)
+		}
+
+		// Create the request. Creating it with context is important for a timeout
+		// to be possible, because the client is configured without a timeout.
+		req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/embeddings", bytes.NewBuffer(reqBody))
+		if err != nil {
+			return nil, fmt.Errorf("couldn't create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+
+		// Send the request.
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't send request: %w", err)
+		}
+		defer resp.Body.Close()
+
+		// Check the response status.
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.New("error response from the embedding API: " + resp.Status)
+		}
+
+		// Read and decode the response body.
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read response body: %w", err)
+		}
+		var embeddingResponse openAIBatchResponse
+		err = json.Unmarshal(body, &embeddingResponse)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't unmarshal response body: %w", err)
+		}
+
+		// The embeddings have an index, as the order isn't guaranteed.
+		res := make([][]float32, len(texts))
+		for _, d := range embeddingResponse.Data {
+			if d.Index < 0 || d.Index >= len(texts) {
+				return nil, fmt.Errorf("invalid embedding index %d in the response", d.Index)
+			}
+			// OpenAI embeddings are normalized
+			res[d.Index] = d.Embedding
+		}
+		for i, v := range res {
+			if len(v) == 0 {
+				return nil, fmt.Errorf("no embedding found in the response for input %d", i)
+			}
+		}
+		return res, nil
+	}
+
+	return func(ctx context.Context, texts []string) ([][]float32, error) {
+		res := make([][]float32, len(texts))
+		var errs []error
+		for start := 0; start < len(texts); start += maxBatchSize {
+			end := min(start+maxBatchSize, len(texts))
+			// Don't send further requests when the context is canceled, but
+			// return the embeddings of the chunks so far.
+			if err := ctx.Err(); err != nil {
+				errs = append(errs, fmt.Errorf("couldn't create embeddings of texts %d to %d: %w", start, len(texts)-1, err))
+				break
+			}
+			embeddings, err := embedChunk(ctx, texts[start:end])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("couldn't create embeddings of texts %d to %d: %w", start, end-1, err))
+				continue
+			}
+			copy(res[start:end], embeddings)
+		}
+		return res, errors.Join(errs...)
+	}
+}
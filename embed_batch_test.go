@@ -0,0 +1,142 @@
+package chromem
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func TestNewBatchedEmbeddingFuncOpenAI(t *testing.T) {
+	apiKey := "secret"
+	model := "model-small"
+
+	// newServer returns a mock server that returns an embedding for each input,
+	// in reverse order, with the input's number as first element. Requests with
+	// the failing input get an error response.
+	newServer := func(t *testing.T, calls *atomic.Int32, maxBatchSize int, failingInput string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			if r.URL.Path != "/embeddings" {
+				t.Fatal("expected path /embeddings, got", r.URL.Path)
+			}
+			if r.Header.Get("Authorization") != "Bearer "+apiKey {
+				t.Fatal("expected Authorization header", "Bearer "+apiKey, "got", r.Header.Get("Authorization"))
+			}
+			var reqBody struct {
+				Input []string `json:"input"`
+				Model string   `json:"model"`
+			}
+			err := json.NewDecoder(r.Body).Decode(&reqBody)
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			if reqBody.Model != model {
+				t.Fatal("expected model", model, "got", reqBody.Model)
+			}
+			if len(reqBody.Input) == 0 || len(reqBody.Input) > maxBatchSize {
+				t.Fatal("expected 1 to", maxBatchSize, "inputs, got", len(reqBody.Input))
+			}
+
+			var resp openAIBatchResponse
+			for i := len(reqBody.Input) - 1; i >= 0; i-- {
+				if reqBody.Input[i] == failingInput {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				n, err := strconv.Atoi(reqBody.Input[i])
+				if err != nil {
+					t.Fatal("unexpected error:", err)
+				}
+				resp.Data = append(resp.Data, struct {
+					Embedding []float32 `json:"embedding"`
+					Index     int       `json:"index"`
+				}{Embedding: []float32{float32(n), 1}, Index: i})
+			}
+			_ = json.NewEncoder(w).Encode(resp)
+		}))
+	}
+
+	texts := func(n int) []string {
+		var res []string
+		for i := 0; i < n; i++ {
+			res = append(res, strconv.Itoa(i))
+		}
+		return res
+	}
+
+	tt := []struct {
+		texts        int
+		maxBatchSize int
+		wantCalls    int
+	}{
+		{texts: 0, maxBatchSize: 10, wantCalls: 0},
+		{texts: 1, maxBatchSize: 10, wantCalls: 1},
+		{texts: 10, maxBatchSize: 10, wantCalls: 1},
+		{texts: 11, maxBatchSize: 10, wantCalls: 2},
+		{texts: 25, maxBatchSize: 10, wantCalls: 3},
+		{texts: 25, maxBatchSize: 1, wantCalls: 25},
+		{texts: 2049, maxBatchSize: 0, wantCalls: 2},
+	}
+	for _, tc := range tt {
+		t.Run(strconv.Itoa(tc.texts)+"/"+strconv.Itoa(tc.maxBatchSize), func(t *testing.T) {
+			var calls atomic.Int32
+			serverMaxBatchSize := tc.maxBatchSize
+			if serverMaxBatchSize <= 0 {
+				serverMaxBatchSize = maxBatchSizeOpenAI
+			}
+			ts := newServer(t, &calls, serverMaxBatchSize, "")
+			defer ts.Close()
+
+			f := newBatchedEmbeddingFuncOpenAI(ts.URL, apiKey, model, tc.maxBatchSize)
+			res, err := f(context.Background(), texts(tc.texts))
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+			if int(calls.Load()) != tc.wantCalls {
+				t.Fatal("expected", tc.wantCalls, "calls, got", calls.Load())
+			}
+			if len(res) != tc.texts {
+				t.Fatal("expected", tc.texts, "embeddings, got", len(res))
+			}
+			for i, v := range res {
+				if len(v) != 2 || v[0] != float32(i) {
+					t.Fatal("expected embedding of text", i, "got", v)
+				}
+			}
+		})
+	}
+
+	t.Run("Partial success", func(t *testing.T) {
+		var calls atomic.Int32
+		// The second of three chunks fails
+		ts := newServer(t, &calls, 10, "15")
+		defer ts.Close()
+
+		f := newBatchedEmbeddingFuncOpenAI(ts.URL, apiKey, model, 10)
+		res, err := f(context.Background(), texts(25))
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if calls.Load() != 3 {
+			t.Fatal("expected 3 calls, got", calls.Load())
+		}
+		if len(res) != 25 {
+			t.Fatal("expected 25 embeddings, got", len(res))
+		}
+		for i, v := range res {
+			if i >= 10 && i < 20 {
+				if v != nil {
+					t.Fatal("expected nil embedding for failed text", i, "got", v)
+				}
+				continue
+			}
+			if len(v) != 2 || v[0] != float32(i) {
+				t.Fatal("expected embedding of text", i, "got", v)
+			}
+		}
+	})
+}
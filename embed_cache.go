@@ -0,0 +1,203 @@
+package chromem
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// embeddingCacheFileExt is the extension of the files of a
+// [DiskBackedEmbeddingCache].
+const embeddingCacheFileExt = ".gob"
+
+// DiskBackedEmbeddingCache caches embeddings in files on disk, so that texts
+// don't have to be embedded again, for example when re-indexing documents or
+// after a restart. When the cache is full, the least recently used entry is
+// evicted and its file deleted.
+//
+// The cache key is the SHA-256 hash of the text, so use a separate directory
+// for each embedding model. Use [DiskBackedEmbeddingCache.Wrap] to add the
+// cache to an embedding function.
+//
+// It's safe for concurrent use, but not for use by multiple processes with the
+// same directory.
+type DiskBackedEmbeddingCache struct {
+	dir        string
+	maxEntries int
+
+	// Access times of the entries, by key. They're the basis for the eviction,
+	// and the modification times of the files are kept in sync, so that the
+	// access times can be restored after a restart.
+	accessTimes map[string]time.Time
+	lock        sync.Mutex
+
+	// For tests
+	now func() time.Time
+}
+
+// NewDiskBackedEmbeddingCache creates a cache that stores up to maxEntries
+// embeddings in the given directory. The directory is created if it doesn't
+// exist. Existing entries are loaded, with the modification times of their
+// files as access times. If there are more entries than maxEntries, the least
+// recently used ones are evicted.
+func NewDiskBackedEmbeddingCache(dir string, maxEntries int) (*DiskBackedEmbeddingCache, error) {
+	if dir == "" {
+		return nil, errors.New("directory is empty")
+	}
+	if maxEntries <= 0 {
+		return nil, errors.New("maxEntries must be > 0")
+	}
+
+	err := os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create cache directory: %w", err)
+	}
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read cache directory: %w", err)
+	}
+
+	c := &DiskBackedEmbeddingCache{
+		dir:         dir,
+		maxEntries:  maxEntries,
+		accessTimes: make(map[string]time.Time, len(dirEntries)),
+		now:         time.Now,
+	}
+	for _, dirEntry := range dirEntries {
+		key, ok := strings.CutSuffix(dirEntry.Name(), embeddingCacheFileExt)
+		if dirEntry.IsDir() || !ok {
+			continue
+		}
+		fi, err := dirEntry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("couldn't get info about cache file: %w", err)
+		}
+		c.accessTimes[key] = fi.ModTime()
+	}
+	for len(c.accessTimes) > c.maxEntries {
+		err = c.evict()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// Get returns the cached embedding of the text, or false if it's not cached.
+func (c *DiskBackedEmbeddingCache) Get(text string) ([]float32, bool) {
+	key := embeddingCacheKey(text)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, ok := c.accessTimes[key]; !ok {
+		return nil, false
+	}
+	var embedding []float32
+	err := readFromFile(c.path(key), &embedding, "")
+	if err != nil {
+		// The file is corrupted or was deleted, so we drop the entry.
+		delete(c.accessTimes, key)
+		_ = removeFile(c.path(key))
+		return nil, false
+	}
+	c.touch(key)
+	return embedding, true
+}
+
+// Set caches the embedding of the text. If the cache is full, the least
+// recently used entry is evicted.
+func (c *DiskBackedEmbeddingCache) Set(text string, embedding []float32) error {
+	key := embeddingCacheKey(text)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, ok := c.accessTimes[key]; !ok && len(c.accessTimes) >= c.maxEntries {
+		err := c.evict()
+		if err != nil {
+			return err
+		}
+	}
+	err := persistToFile(c.path(key), embedding, false, "")
+	if err != nil {
+		return fmt.Errorf("couldn't write cache file: %w", err)
+	}
+	c.touch(key)
+	return nil
+}
+
+// Len returns the number of cached embeddings.
+func (c *DiskBackedEmbeddingCache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.accessTimes)
+}
+
+// Wrap returns an embedding function that returns the cached embedding of a
+// text if there is one, and otherwise calls embed and caches the result.
+// Errors when writing to the cache are ignored, as the embedding is valid
+// nonetheless.
+func (c *DiskBackedEmbeddingCache) Wrap(embed EmbeddingFunc) EmbeddingFunc {
+	return func(ctx context.Context, text string) ([]float32, error) {
+		if embedding, ok := c.Get(text); ok {
+			return embedding, nil
+		}
+		embedding, err := embed(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		_ = c.Set(text, embedding)
+		return embedding, nil
+	}
+}
+
+// evict removes the least recently used entry.
+// The caller must hold the lock.
+func (c *DiskBackedEmbeddingCache) evict() error {
+	var oldestKey string
+	var oldestTime time.Time
+	for key, t := range c.accessTimes {
+		if oldestKey == "" || t.Before(oldestTime) {
+			oldestKey, oldestTime = key, t
+		}
+	}
+	if oldestKey == "" {
+		return nil
+	}
+	err := removeFile(c.path(oldestKey))
+	if err != nil {
+		return fmt.Errorf("couldn't evict cache entry: %w", err)
+	}
+	delete(c.accessTimes, oldestKey)
+	return nil
+}
+
+// touch sets the access time of the entry to now, in memory and as modification
+// time of its file.
+// The caller must hold the lock.
+func (c *DiskBackedEmbeddingCache) touch(key string) {
+	now := c.now()
+	c.accessTimes[key] = now
+	// If this fails, only the order after a restart is affected.
+	_ = os.Chtimes(c.path(key), now, now)
+}
+
+// path returns the path of the cache file of the entry.
+func (c *DiskBackedEmbeddingCache) path(key string) string {
+	return filepath.Join(c.dir, key+embeddingCacheFileExt)
+}
+
+// embeddingCacheKey returns the cache key of the text.
+func embeddingCacheKey(text string) string {
+	hash := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(hash[:])
+}
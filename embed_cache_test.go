@@ -0,0 +1,148 @@
+package chromem
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newTestEmbeddingCache creates a cache with a clock that advances by a second
+// on each call, so that the access times are distinct.
+func newTestEmbeddingCache(t *testing.T, dir string, maxEntries int) *DiskBackedEmbeddingCache {
+	t.Helper()
+	c, err := NewDiskBackedEmbeddingCache(dir, maxEntries)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	return c
+}
+
+func TestDiskBackedEmbeddingCache(t *testing.T) {
+	dir := t.TempDir()
+	c := newTestEmbeddingCache(t, dir, 3)
+
+	for i := 0; i < 3; i++ {
+		err := c.Set("text "+strconv.Itoa(i), []float32{float32(i), 1})
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+	}
+	if c.Len() != 3 {
+		t.Fatal("expected 3 entries, got", c.Len())
+	}
+
+	// Accessing the oldest entry makes "text 1" the least recently used one
+	v, ok := c.Get("text 0")
+	if !ok || !slices.Equal([]float32{0, 1}, v) {
+		t.Fatal("expected cached embedding [0 1], got", v, ok)
+	}
+
+	// Beyond capacity, the least recently used entry's file is deleted
+	err := c.Set("text 3", []float32{3, 1})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if c.Len() != 3 {
+		t.Fatal("expected 3 entries, got", c.Len())
+	}
+	if _, err := os.Stat(c.path(embeddingCacheKey("text 1"))); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("expected file of evicted entry to be deleted, got", err)
+	}
+	if _, ok := c.Get("text 1"); ok {
+		t.Fatal("expected evicted entry to be missing")
+	}
+	for _, text := range []string{"text 0", "text 2", "text 3"} {
+		if _, ok := c.Get(text); !ok {
+			t.Fatal("expected entry", text, "to be cached")
+		}
+	}
+
+	// Overwriting an existing entry doesn't evict
+	err = c.Set("text 3", []float32{3, 2})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if c.Len() != 3 {
+		t.Fatal("expected 3 entries, got", c.Len())
+	}
+
+	// After a restart, the cache is restored from the directory, with the
+	// modification times as access times. The order of the accesses above was
+	// 0, 2, 3, so with a smaller capacity 0 is evicted first.
+	c2 := newTestEmbeddingCache(t, dir, 2)
+	if c2.Len() != 2 {
+		t.Fatal("expected 2 entries, got", c2.Len())
+	}
+	if _, ok := c2.Get("text 0"); ok {
+		t.Fatal("expected entry text 0 to be evicted")
+	}
+	v, ok = c2.Get("text 3")
+	if !ok || !slices.Equal([]float32{3, 2}, v) {
+		t.Fatal("expected cached embedding [3 2], got", v, ok)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if len(entries) != 2 {
+		t.Fatal("expected 2 cache files, got", len(entries))
+	}
+}
+
+func TestDiskBackedEmbeddingCache_Wrap(t *testing.T) {
+	ctx := context.Background()
+	c := newTestEmbeddingCache(t, t.TempDir(), 10)
+
+	var calls int
+	embeddingFunc := c.Wrap(func(_ context.Context, text string) ([]float32, error) {
+		calls++
+		if text == "fail" {
+			return nil, errors.New("embedding error")
+		}
+		return []float32{float32(len(text)), 1}, nil
+	})
+
+	for i := 0; i < 2; i++ {
+		v, err := embeddingFunc(ctx, "hello")
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		if !slices.Equal([]float32{5, 1}, v) {
+			t.Fatal("expected [5 1], got", v)
+		}
+	}
+	if calls != 1 {
+		t.Fatal("expected 1 call, got", calls)
+	}
+
+	// Errors aren't cached
+	for i := 0; i < 2; i++ {
+		_, err := embeddingFunc(ctx, "fail")
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	}
+	if calls != 3 {
+		t.Fatal("expected 3 calls, got", calls)
+	}
+}
+
+func TestNewDiskBackedEmbeddingCache_Error(t *testing.T) {
+	_, err := NewDiskBackedEmbeddingCache("", 10)
+	if err == nil {
+		t.Fatal("expected error for empty directory, got nil")
+	}
+	_, err = NewDiskBackedEmbeddingCache(t.TempDir(), 0)
+	if err == nil {
+		t.Fatal("expected error for maxEntries 0, got nil")
+	}
+}
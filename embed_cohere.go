@@ -82,6 +82,10 @@ type cohereResponse struct {
 //
 // We plan to improve this in the future.
 func NewEmbeddingFuncCohere(apiKey string, model EmbeddingModelCohere) EmbeddingFunc {
+	return newEmbeddingFuncCohere(baseURLCohere, apiKey, model)
+}
+
+func newEmbeddingFuncCohere(baseURL, apiKey string, model EmbeddingModelCohere) EmbeddingFunc {
 	// We don't set a default timeout here, although it's usually a good idea.
 	// In our case though, the library user can set the timeout on the context,
 	// and it might have to be a long timeout, depending on the text length.
@@ -115,7 +119,7 @@ func NewEmbeddingFuncCohere(apiKey string, model EmbeddingModelCohere) Embedding
 
 		// Create the request. Creating it with context is important for a timeout
 		// to be possible, because the client is configured without a timeout.
-		req, err := http.NewRequestWithContext(ctx, "POST", baseURLCohere+"/embed", bytes.NewBuffer(reqBody))
+		req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/embed", bytes.NewBuffer(reqBody))
 		if err != nil {
 			return nil, fmt.Errorf("couldn't create request: %w", err)
 		}
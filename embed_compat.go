@@ -1,5 +1,17 @@
 package chromem
 
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
 const (
 	baseURLMistral = "https://api.mistral.ai/v1"
 	// Currently there's only one. Let's turn this into a pseudo-enum as soon as there are more.
@@ -99,5 +111,150 @@ func NewEmbeddingFuncAzureOpenAI(apiKey string, deploymentURL string, apiVersion
 	if apiVersion == "" {
 		apiVersion = azureDefaultAPIVersion
 	}
-	return newEmbeddingFuncOpenAICompat(deploymentURL, apiKey, model, nil, map[string]string{"api-key": apiKey}, map[string]string{"api-version": apiVersion})
+	return newEmbeddingFuncOpenAICompat(deploymentURL, apiKey, model, nil, map[string]string{"api-key": apiKey}, map[string]string{"api-version": apiVersion}, nil)
+}
+
+const baseURLCFAIGateway = "https://gateway.ai.cloudflare.com/v1"
+
+// NewEmbeddingFuncCFAIGateway returns a function that creates embeddings for a
+// text using a provider behind the Cloudflare AI Gateway, which adds caching,
+// rate limiting and logging.
+// The gateway forwards the requests as is, so the request body and the header
+// with the provider API key are the ones of the provider. Supported providers:
+//
+//   - "openai" and "mistral": OpenAI compatible requests to
+//     "{provider}/embeddings". The provider is added to the URL as is, so for
+//     providers with a version in their path you can pass it along, like
+//     "mistral/v1".
+//   - "azure-openai": Like [NewEmbeddingFuncAzureOpenAI], with the resource and
+//     deployment in the provider, like "azure-openai/my-resource/my-deployment".
+//   - "cohere": Like [NewEmbeddingFuncCohere], including the input type prefix
+//     of the text.
+//   - "workers-ai": Workers AI text embedding models like
+//     "@cf/baai/bge-base-en-v1.5", with a Cloudflare API token as provider API
+//     key. The model is added to the URL instead of "embeddings".
+//
+// For other providers, the returned function returns an error.
+//
+// See https://developers.cloudflare.com/ai-gateway/providers/
+func NewEmbeddingFuncCFAIGateway(accountTag, gatewayID, provider, providerAPIKey, model string) EmbeddingFunc {
+	return newEmbeddingFuncCFAIGateway(baseURLCFAIGateway, accountTag, gatewayID, provider, providerAPIKey, model)
+}
+
+func newEmbeddingFuncCFAIGateway(baseURL, accountTag, gatewayID, provider, providerAPIKey, model string) EmbeddingFunc {
+	gatewayURL := baseURL + "/" + accountTag + "/" + gatewayID + "/" + provider
+	providerName, _, _ := strings.Cut(provider, "/")
+	switch providerName {
+	case "openai", "mistral":
+		return NewEmbeddingFuncOpenAICompat(gatewayURL, providerAPIKey, model, nil)
+	case "azure-openai":
+		return newEmbeddingFuncOpenAICompat(gatewayURL, providerAPIKey, model, nil, map[string]string{"api-key": providerAPIKey}, map[string]string{"api-version": azureDefaultAPIVersion}, nil)
+	case "cohere":
+		return newEmbeddingFuncCohere(gatewayURL+"/v1", providerAPIKey, EmbeddingModelCohere(model))
+	case "workers-ai":
+		return newEmbeddingFuncWorkersAI(gatewayURL+"/"+model, providerAPIKey)
+	default:
+		return func(_ context.Context, _ string) ([]float32, error) {
+			return nil, fmt.Errorf("provider %q is not supported for the Cloudflare AI Gateway", provider)
+		}
+	}
+}
+
+type workersAIResponse struct {
+	Result struct {
+		Data [][]float32 `json:"data"`
+	} `json:"result"`
+}
+
+// newEmbeddingFuncWorkersAI returns a function that creates embeddings for a
+// text using the Workers AI model at the given URL.
+func newEmbeddingFuncWorkersAI(modelURL, apiToken string) EmbeddingFunc {
+	// We don't set a default timeout here, although it's usually a good idea.
+	// In our case though, the library user can set the timeout on the context,
+	// and it might have to be a long timeout, depending on the text length.
+	client := &http.Client{}
+
+	return func(ctx context.Context, text string) ([]float32, error) {
+		// Prepare the request body.
+		reqBody, err := json.Marshal(map[string]string{
+			"text": text,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("couldn't marshal request body: %w", err)
+		}
+
+		// Create the request. Creating it with context is important for a timeout
+		// to be possible, because the client is configured without a timeout.
+		req, err := http.NewRequestWithContext(ctx, "POST", modelURL, bytes.NewBuffer(reqBody))
+		if err != nil {
+			return nil, fmt.Errorf("couldn't create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+apiToken)
+
+		// Send the request.
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't send request: %w", err)
+		}
+		defer resp.Body.Close()
+
+		// Check the response status.
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.New("error response from the embedding API: " + resp.Status)
+		}
+
+		// Read and decode the response body.
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read response body: %w", err)
+		}
+		var embeddingResponse workersAIResponse
+		err = json.Unmarshal(body, &embeddingResponse)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't unmarshal response body: %w", err)
+		}
+
+		// Check if the response contains embeddings.
+		if len(embeddingResponse.Result.Data) == 0 || len(embeddingResponse.Result.Data[0]) == 0 {
+			return nil, errors.New("no embeddings found in the response")
+		}
+
+		v := embeddingResponse.Result.Data[0]
+		if !isNormalized(v) {
+			v = normalizeVector(v)
+		}
+		return v, nil
+	}
+}
+
+const baseURLDeepSeek = "https://api.deepseek.com/v1"
+
+// NewEmbeddingFuncDeepSeek returns a function that creates embeddings for a text
+// using the DeepSeek API.
+func NewEmbeddingFuncDeepSeek(apiKey, model string) EmbeddingFunc {
+	return newEmbeddingFuncDeepSeek(baseURLDeepSeek, apiKey, model)
+}
+
+// NewEmbeddingFuncDeepSeekWithTimeout is like [NewEmbeddingFuncDeepSeek], but
+// each call of the returned function times out after the given duration. The
+// timeout is applied in addition to any deadline of the context.
+func NewEmbeddingFuncDeepSeekWithTimeout(apiKey, model string, timeout time.Duration) EmbeddingFunc {
+	return embeddingFuncWithTimeout(NewEmbeddingFuncDeepSeek(apiKey, model), timeout)
+}
+
+func newEmbeddingFuncDeepSeek(baseURL, apiKey, model string) EmbeddingFunc {
+	// The DeepSeek API is OpenAI compatible, including the request and response
+	// schema.
+	return NewEmbeddingFuncOpenAICompat(baseURL, apiKey, model, nil)
+}
+
+// embeddingFuncWithTimeout wraps the embedding function so that each call times
+// out after the given duration.
+func embeddingFuncWithTimeout(embed EmbeddingFunc, timeout time.Duration) EmbeddingFunc {
+	return func(ctx context.Context, text string) ([]float32, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return embed(ctx, text)
+	}
 }
@@ -0,0 +1,234 @@
+package chromem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewEmbeddingFuncDeepSeek(t *testing.T) {
+	apiKey := "secret"
+	model := "deepseek-embedding"
+	input := "hello world"
+	wantRes := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+
+	// Mock server
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Check URL
+		if r.URL.Path != "/v1/embeddings" {
+			t.Fatal("expected URL /v1/embeddings, got", r.URL.Path)
+		}
+		// Check headers
+		if r.Header.Get("Authorization") != "Bearer "+apiKey {
+			t.Fatal("expected Authorization header", "Bearer "+apiKey, "got", r.Header.Get("Authorization"))
+		}
+		// Check body
+		var body map[string]any
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			t.Fatal("unexpected error:", err)
+		}
+		if body["input"] != input || body["model"] != model {
+			t.Fatal("expected input and model", input, model, "got", body)
+		}
+
+		// Write response
+		_, _ = w.Write([]byte(`{"data":[{"embedding":[-0.40824828,0.40824828,0.81649655]}]}`))
+	}))
+	defer ts.Close()
+
+	f := newEmbeddingFuncDeepSeek(ts.URL+"/v1", apiKey, model)
+	res, err := f(context.Background(), input)
+	if err != nil {
+		t.Fatal("expected nil, got", err)
+	}
+	if !slices.Equal(wantRes, res) {
+		t.Fatal("expected res", wantRes, "got", res)
+	}
+}
+
+func TestNewEmbeddingFuncDeepSeek_HTTPError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	f := newEmbeddingFuncDeepSeek(ts.URL+"/v1", "wrong", "deepseek-embedding")
+	_, err := f(context.Background(), "hello world")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "401") {
+		t.Fatal("expected error to contain the status code, got", err)
+	}
+}
+
+func TestEmbeddingFuncWithTimeout(t *testing.T) {
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-done
+		_, _ = w.Write([]byte(`{"data":[{"embedding":[-0.40824828,0.40824828,0.81649655]}]}`))
+	}))
+	defer ts.Close()
+	// Deferred functions run in reverse order, so this unblocks the handler
+	// before the server is closed.
+	defer close(done)
+
+	f := embeddingFuncWithTimeout(newEmbeddingFuncDeepSeek(ts.URL+"/v1", "secret", "deepseek-embedding"), 10*time.Millisecond)
+	_, err := f(context.Background(), "hello world")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("expected context.DeadlineExceeded, got", err)
+	}
+}
+
+func TestNewEmbeddingFuncCFAIGateway(t *testing.T) {
+	providerAPIKey := "secret"
+	model := "text-embedding-3-small"
+	wantRes := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+
+	// Mock server
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Check URL
+		wantPath := "/v1/my-account/my-gateway/openai/embeddings"
+		if r.URL.Path != wantPath {
+			t.Fatal("expected URL", wantPath, "got", r.URL.Path)
+		}
+		// Check forwarded headers
+		if r.Header.Get("Authorization") != "Bearer "+providerAPIKey {
+			t.Fatal("expected Authorization header", "Bearer "+providerAPIKey, "got", r.Header.Get("Authorization"))
+		}
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Fatal("expected Content-Type header", "application/json", "got", r.Header.Get("Content-Type"))
+		}
+		// Check body
+		var body map[string]any
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			t.Fatal("unexpected error:", err)
+		}
+		if body["input"] != "hello world" || body["model"] != model {
+			t.Fatal("expected input and model", "hello world", model, "got", body)
+		}
+
+		// Write response
+		_, _ = w.Write([]byte(`{"data":[{"embedding":[-0.40824828,0.40824828,0.81649655]}]}`))
+	}))
+	defer ts.Close()
+
+	f := newEmbeddingFuncCFAIGateway(ts.URL+"/v1", "my-account", "my-gateway", "openai", providerAPIKey, model)
+	res, err := f(context.Background(), "hello world")
+	if err != nil {
+		t.Fatal("expected nil, got", err)
+	}
+	if !slices.Equal(wantRes, res) {
+		t.Fatal("expected res", wantRes, "got", res)
+	}
+}
+
+func TestNewEmbeddingFuncCFAIGateway_Providers(t *testing.T) {
+	providerAPIKey := "secret"
+	wantRes := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+
+	tt := []struct {
+		name       string
+		provider   string
+		model      string
+		text       string
+		wantPath   string
+		wantHeader map[string]string
+		wantQuery  string
+		wantBody   map[string]any
+		response   string
+	}{
+		{
+			name:       "Azure OpenAI",
+			provider:   "azure-openai/my-resource/my-deployment",
+			model:      "text-embedding-3-small",
+			text:       "hello world",
+			wantPath:   "/v1/my-account/my-gateway/azure-openai/my-resource/my-deployment/embeddings",
+			wantHeader: map[string]string{"api-key": providerAPIKey},
+			wantQuery:  "api-version=" + azureDefaultAPIVersion,
+			wantBody:   map[string]any{"input": "hello world", "model": "text-embedding-3-small"},
+			response:   `{"data":[{"embedding":[-0.1,0.1,0.2]}]}`,
+		},
+		{
+			name:       "Cohere",
+			provider:   "cohere",
+			model:      string(EmbeddingModelCohereEnglishV3),
+			text:       InputTypeCohereSearchDocumentPrefix + "hello world",
+			wantPath:   "/v1/my-account/my-gateway/cohere/v1/embed",
+			wantHeader: map[string]string{"Authorization": "Bearer " + providerAPIKey},
+			wantBody:   map[string]any{"texts": []any{"hello world"}, "model": string(EmbeddingModelCohereEnglishV3), "input_type": "search_document"},
+			response:   `{"embeddings":[[-0.1,0.1,0.2]]}`,
+		},
+		{
+			name:       "Workers AI",
+			provider:   "workers-ai",
+			model:      "@cf/baai/bge-base-en-v1.5",
+			text:       "hello world",
+			wantPath:   "/v1/my-account/my-gateway/workers-ai/@cf/baai/bge-base-en-v1.5",
+			wantHeader: map[string]string{"Authorization": "Bearer " + providerAPIKey},
+			wantBody:   map[string]any{"text": "hello world"},
+			response:   `{"result":{"shape":[1,3],"data":[[-0.1,0.1,0.2]]},"success":true}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			// Mock server
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tc.wantPath {
+					t.Fatal("expected URL", tc.wantPath, "got", r.URL.Path)
+				}
+				if r.URL.RawQuery != tc.wantQuery {
+					t.Fatal("expected query", tc.wantQuery, "got", r.URL.RawQuery)
+				}
+				for k, v := range tc.wantHeader {
+					if r.Header.Get(k) != v {
+						t.Fatal("expected header", k, v, "got", r.Header.Get(k))
+					}
+				}
+				var body map[string]any
+				err := json.NewDecoder(r.Body).Decode(&body)
+				if err != nil {
+					t.Fatal("unexpected error:", err)
+				}
+				if !reflect.DeepEqual(tc.wantBody, body) {
+					t.Fatal("expected body", tc.wantBody, "got", body)
+				}
+
+				_, _ = w.Write([]byte(tc.response))
+			}))
+			defer ts.Close()
+
+			f := newEmbeddingFuncCFAIGateway(ts.URL+"/v1", "my-account", "my-gateway", tc.provider, providerAPIKey, tc.model)
+			res, err := f(context.Background(), tc.text)
+			if err != nil {
+				t.Fatal("expected nil, got", err)
+			}
+			if !slices.Equal(wantRes, res) {
+				t.Fatal("expected res", wantRes, "got", res)
+			}
+		})
+	}
+
+	t.Run("Unsupported provider", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Fatal("expected no request, got one to", r.URL.Path)
+		}))
+		defer ts.Close()
+
+		f := newEmbeddingFuncCFAIGateway(ts.URL+"/v1", "my-account", "my-gateway", "anthropic", providerAPIKey, "some-model")
+		_, err := f(context.Background(), "hello world")
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package chromem
+
+import (
+	"context"
+	"errors"
+)
+
+// embeddingFuncContextKey is the context key for the embedding function stored
+// via [WithEmbeddingFunc].
+type embeddingFuncContextKey struct{}
+
+// WithEmbeddingFunc returns a copy of the context that carries the given
+// embedding function. Together with [NewContextEmbeddingFunc] this allows to
+// select the embedding function per request, for example based on the
+// authenticated user in a multi-tenant system.
+func WithEmbeddingFunc(ctx context.Context, fn EmbeddingFunc) context.Context {
+	return context.WithValue(ctx, embeddingFuncContextKey{}, fn)
+}
+
+// EmbeddingFuncFromContext returns the embedding function stored in the context
+// via [WithEmbeddingFunc], or nil if there is none.
+func EmbeddingFuncFromContext(ctx context.Context) EmbeddingFunc {
+	fn, _ := ctx.Value(embeddingFuncContextKey{}).(EmbeddingFunc)
+	return fn
+}
+
+// NewContextEmbeddingFunc returns a function that selects the embedding function
+// to use based on the context of each call. It calls extract with the context
+// and uses the returned function. If extract returns nil, fallback is used.
+//
+// If extract is nil, [EmbeddingFuncFromContext] is used, so you can pass the
+// embedding function per request via [WithEmbeddingFunc]:
+//
+//	embeddingFunc := chromem.NewContextEmbeddingFunc(nil, chromem.NewEmbeddingFuncDefault())
+//	c, _ := db.CreateCollection("tenants", nil, embeddingFunc)
+//	ctx = chromem.WithEmbeddingFunc(ctx, tenantEmbeddingFunc)
+//	_ = c.AddDocument(ctx, doc)
+//
+// Keep in mind that all documents in a collection must be embedded with the
+// same model to be comparable.
+func NewContextEmbeddingFunc(extract func(ctx context.Context) EmbeddingFunc, fallback EmbeddingFunc) EmbeddingFunc {
+	if extract == nil {
+		extract = EmbeddingFuncFromContext
+	}
+
+	return func(ctx context.Context, text string) ([]float32, error) {
+		fn := extract(ctx)
+		if fn == nil {
+			fn = fallback
+		}
+		if fn == nil {
+			return nil, errors.New("no embedding function in context and no fallback")
+		}
+		return fn(ctx, text)
+	}
+}
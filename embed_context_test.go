@@ -0,0 +1,93 @@
+package chromem
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestNewContextEmbeddingFunc(t *testing.T) {
+	ctx := context.Background()
+	vectorsA := []float32{1, 0, 0}
+	vectorsB := []float32{0, 1, 0}
+	vectorsFallback := []float32{0, 0, 1}
+	embeddingFuncA := func(_ context.Context, _ string) ([]float32, error) {
+		return vectorsA, nil
+	}
+	embeddingFuncB := func(_ context.Context, _ string) ([]float32, error) {
+		return vectorsB, nil
+	}
+	fallback := func(_ context.Context, _ string) ([]float32, error) {
+		return vectorsFallback, nil
+	}
+
+	db := NewDB()
+	c, err := db.CreateCollection("test", nil, NewContextEmbeddingFunc(nil, fallback))
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	// Add documents concurrently with different embedding funcs in the context
+	ctxA := WithEmbeddingFunc(ctx, embeddingFuncA)
+	ctxB := WithEmbeddingFunc(ctx, embeddingFuncB)
+	n := 50
+	errs := make(chan error, 2*n)
+	wg := sync.WaitGroup{}
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			errs <- c.Add(ctxA, []string{"a" + strconv.Itoa(i)}, nil, nil, []string{"hello world"})
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			errs <- c.Add(ctxB, []string{"b" + strconv.Itoa(i)}, nil, nil, []string{"hello world"})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal("expected nil, got", err)
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		docA, err := c.GetByID(ctx, "a"+strconv.Itoa(i))
+		if err != nil {
+			t.Fatal("expected nil, got", err)
+		}
+		if !slices.Equal(docA.Embedding, vectorsA) {
+			t.Fatal("expected", vectorsA, "got", docA.Embedding)
+		}
+		docB, err := c.GetByID(ctx, "b"+strconv.Itoa(i))
+		if err != nil {
+			t.Fatal("expected nil, got", err)
+		}
+		if !slices.Equal(docB.Embedding, vectorsB) {
+			t.Fatal("expected", vectorsB, "got", docB.Embedding)
+		}
+	}
+
+	// Without embedding func in the context, the fallback is used
+	err = c.Add(ctx, []string{"c"}, nil, nil, []string{"hello world"})
+	if err != nil {
+		t.Fatal("expected nil, got", err)
+	}
+	docC, err := c.GetByID(ctx, "c")
+	if err != nil {
+		t.Fatal("expected nil, got", err)
+	}
+	if !slices.Equal(docC.Embedding, vectorsFallback) {
+		t.Fatal("expected", vectorsFallback, "got", docC.Embedding)
+	}
+
+	// Without fallback, it's an error
+	f := NewContextEmbeddingFunc(nil, nil)
+	_, err = f(ctx, "hello world")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
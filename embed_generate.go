@@ -0,0 +1,5 @@
+package chromem
+
+// Embedding functions for providers with a simple JSON API are generated from
+// the spec files in the embedspecs directory.
+//go:generate go run ./cmd/gen-embedding-func -specs ./embedspecs -out .
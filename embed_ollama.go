@@ -13,6 +13,10 @@ import (
 
 const defaultBaseURLOllama = "http://localhost:11434/api"
 
+func init() {
+	RegisterEmbeddingFunc("ollama", NewEmbeddingFuncOllama("nomic-embed-text", ""))
+}
+
 type ollamaResponse struct {
 	Embedding []float32 `json:"embedding"`
 }
@@ -23,12 +23,30 @@ const (
 	EmbeddingModelOpenAI3Large EmbeddingModelOpenAI = "text-embedding-3-large"
 )
 
+// DefaultEmbeddingPricePerToken is the price in USD per input token of OpenAI's
+// "text-embedding-3-small" model, which is the default embedding model.
+// It's used for cost estimations. See [Collection.QueryDryRun].
+const DefaultEmbeddingPricePerToken = 0.02 / 1_000_000
+
+// charsPerToken is the rule of thumb for the number of characters per token
+// for English text with OpenAI's tokenizers.
+const charsPerToken = 4
+
 type openAIResponse struct {
 	Data []struct {
 		Embedding []float32 `json:"embedding"`
 	} `json:"data"`
 }
 
+func init() {
+	// Create the function lazily, so the API key is read from the environment
+	// on first use and not at program start.
+	defaultFunc := sync.OnceValue(NewEmbeddingFuncDefault)
+	RegisterEmbeddingFunc("openai", func(ctx context.Context, text string) ([]float32, error) {
+		return defaultFunc()(ctx, text)
+	})
+}
+
 // NewEmbeddingFuncDefault returns a function that creates embeddings for a text
 // using OpenAI`s "text-embedding-3-small" model via their API.
 // The model supports a maximum text length of 8191 tokens.
@@ -46,6 +64,52 @@ func NewEmbeddingFuncOpenAI(apiKey string, model EmbeddingModelOpenAI) Embedding
 	return NewEmbeddingFuncOpenAICompat(BaseURLOpenAI, apiKey, string(model), &normalized)
 }
 
+// OpenAIEmbeddingOptions are the options for [NewEmbeddingFuncOpenAIWithOptions].
+type OpenAIEmbeddingOptions struct {
+	// Model is the embedding model to use, for example one of the
+	// EmbeddingModelOpenAI constants or the name of a fine-tuned model.
+	// Optional, defaults to [EmbeddingModelOpenAI3Small].
+	Model string
+
+	// Dimensions is the number of dimensions the resulting embeddings should
+	// have. Only supported by "text-embedding-3" and later models. Optional, if
+	// 0 the model's default is used.
+	Dimensions int
+
+	// User is a unique identifier representing your end-user, which can help
+	// OpenAI to monitor and detect abuse. Optional.
+	User string
+
+	// BaseURL is the base URL of the OpenAI API. Optional, defaults to
+	// [BaseURLOpenAI].
+	BaseURL string
+}
+
+// NewEmbeddingFuncOpenAIWithOptions returns a function that creates embeddings
+// for a text using the OpenAI API, with additional request options like the
+// number of dimensions of the resulting embeddings.
+func NewEmbeddingFuncOpenAIWithOptions(apiKey string, opts OpenAIEmbeddingOptions) EmbeddingFunc {
+	if opts.Model == "" {
+		opts.Model = string(EmbeddingModelOpenAI3Small)
+	}
+	if opts.BaseURL == "" {
+		opts.BaseURL = BaseURLOpenAI
+	}
+
+	body := map[string]any{}
+	if opts.Dimensions > 0 {
+		body["dimensions"] = opts.Dimensions
+	}
+	if opts.User != "" {
+		body["user"] = opts.User
+	}
+
+	// OpenAI embeddings are normalized, including the ones that are shortened
+	// via the dimensions parameter.
+	normalized := true
+	return newEmbeddingFuncOpenAICompat(opts.BaseURL, apiKey, opts.Model, &normalized, nil, nil, body)
+}
+
 // NewEmbeddingFuncOpenAICompat returns a function that creates embeddings for a text
 // using an OpenAI compatible API. For example:
 //   - Azure OpenAI: https://azure.microsoft.com/en-us/products/ai-services/openai-service
@@ -58,19 +122,21 @@ func NewEmbeddingFuncOpenAI(apiKey string, model EmbeddingModelOpenAI) Embedding
 // The flag is optional. If it's nil, it will be autodetected on the first request
 // (which bears a small risk that the vector just happens to have a length of 1).
 func NewEmbeddingFuncOpenAICompat(baseURL, apiKey, model string, normalized *bool) EmbeddingFunc {
-	return newEmbeddingFuncOpenAICompat(baseURL, apiKey, model, normalized, nil, nil)
+	return newEmbeddingFuncOpenAICompat(baseURL, apiKey, model, normalized, nil, nil, nil)
 }
 
 // newEmbeddingFuncOpenAICompat returns a function that creates embeddings for a text
 // using an OpenAI compatible API.
 // It offers options to set request headers and query parameters
 // e.g. to pass the `api-key` header and the `api-version` query parameter for Azure OpenAI.
+// Additional fields for the request body, like `dimensions` for OpenAI's
+// "text-embedding-3" models, can be passed via `body`.
 //
 // The `normalized` parameter indicates whether the vectors returned by the embedding
 // model are already normalized, as is the case for OpenAI's and Mistral's models.
 // The flag is optional. If it's nil, it will be autodetected on the first request
 // (which bears a small risk that the vector just happens to have a length of 1).
-func newEmbeddingFuncOpenAICompat(baseURL, apiKey, model string, normalized *bool, headers map[string]string, queryParams map[string]string) EmbeddingFunc {
+func newEmbeddingFuncOpenAICompat(baseURL, apiKey, model string, normalized *bool, headers map[string]string, queryParams map[string]string, body map[string]any) EmbeddingFunc {
 	// We don't set a default timeout here, although it's usually a good idea.
 	// In our case though, the library user can set the timeout on the context,
 	// and it might have to be a long timeout, depending on the text length.
@@ -81,10 +147,14 @@ func newEmbeddingFuncOpenAICompat(baseURL, apiKey, model string, normalized *boo
 
 	return func(ctx context.Context, text string) ([]float32, error) {
 		// Prepare the request body.
-		reqBody, err := json.Marshal(map[string]string{
+		b := map[string]any{
 			"input": text,
 			"model": model,
-		})
+		}
+		for k, v := range body {
+			b[k] = v
+		}
+		reqBody, err := json.Marshal(b)
 		if err != nil {
 			return nil, fmt.Errorf("couldn't marshal request body: %w", err)
 		}
@@ -159,3 +229,9 @@ func newEmbeddingFuncOpenAICompat(baseURL, apiKey, model string, normalized *boo
 		return v, nil
 	}
 }
+
+// estimateTokens approximates the number of tokens of a text, based on its
+// length. It doesn't tokenize the text, so it's only a rough estimate.
+func estimateTokens(text string) int {
+	return (len(text) + charsPerToken - 1) / charsPerToken
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"slices"
 	"strings"
 	"testing"
@@ -84,3 +85,86 @@ func TestNewEmbeddingFuncOpenAICompat(t *testing.T) {
 		t.Fatal("expected res", wantRes, "got", res)
 	}
 }
+
+func TestNewEmbeddingFuncOpenAIWithOptions(t *testing.T) {
+	apiKey := "secret"
+	input := "hello world"
+	wantRes := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+
+	tt := []struct {
+		name     string
+		opts     chromem.OpenAIEmbeddingOptions
+		wantBody map[string]any
+	}{
+		{
+			name: "Defaults",
+			opts: chromem.OpenAIEmbeddingOptions{},
+			wantBody: map[string]any{
+				"input": input,
+				"model": string(chromem.EmbeddingModelOpenAI3Small),
+			},
+		},
+		{
+			name: "With dimensions and user",
+			opts: chromem.OpenAIEmbeddingOptions{
+				Model:      string(chromem.EmbeddingModelOpenAI3Large),
+				Dimensions: 256,
+				User:       "user-123",
+			},
+			wantBody: map[string]any{
+				"input":      input,
+				"model":      string(chromem.EmbeddingModelOpenAI3Large),
+				"dimensions": float64(256), // JSON numbers are decoded as float64
+				"user":       "user-123",
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			// Mock server
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// Check URL
+				if !strings.HasSuffix(r.URL.Path, "/v1/embeddings") {
+					t.Fatal("expected URL", "/v1/embeddings", "got", r.URL.Path)
+				}
+				// Check headers
+				if r.Header.Get("Authorization") != "Bearer "+apiKey {
+					t.Fatal("expected Authorization header", "Bearer "+apiKey, "got", r.Header.Get("Authorization"))
+				}
+				// Check body
+				gotBody := map[string]any{}
+				err := json.NewDecoder(r.Body).Decode(&gotBody)
+				if err != nil {
+					t.Fatal("unexpected error:", err)
+				}
+				if !reflect.DeepEqual(tc.wantBody, gotBody) {
+					t.Fatal("expected body", tc.wantBody, "got", gotBody)
+				}
+
+				// Write response
+				resp := openAIResponse{
+					Data: []struct {
+						Embedding []float32 `json:"embedding"`
+					}{
+						{Embedding: wantRes},
+					},
+				}
+				w.WriteHeader(http.StatusOK)
+				_ = json.NewEncoder(w).Encode(resp)
+			}))
+			defer ts.Close()
+
+			opts := tc.opts
+			opts.BaseURL = ts.URL + "/v1"
+			f := chromem.NewEmbeddingFuncOpenAIWithOptions(apiKey, opts)
+			res, err := f(context.Background(), input)
+			if err != nil {
+				t.Fatal("expected nil, got", err)
+			}
+			if slices.Compare(wantRes, res) != 0 {
+				t.Fatal("expected res", wantRes, "got", res)
+			}
+		})
+	}
+}
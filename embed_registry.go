@@ -0,0 +1,105 @@
+package chromem
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+)
+
+var (
+	embeddingFuncRegistry     = make(map[string]EmbeddingFunc)
+	embeddingFuncRegistryLock sync.RWMutex
+)
+
+func init() {
+	// "openai" and "ollama" are registered in their own files. The providers
+	// here only need an API key, which we read from the environment.
+	registerEmbeddingFuncFromEnv("mistral", "MISTRAL_API_KEY", NewEmbeddingFuncMistral)
+	registerEmbeddingFuncFromEnv("jina", "JINA_API_KEY", func(apiKey string) EmbeddingFunc {
+		return NewEmbeddingFuncJina(apiKey, EmbeddingModelJina2BaseEN)
+	})
+	registerEmbeddingFuncFromEnv("mixedbread", "MIXEDBREAD_API_KEY", func(apiKey string) EmbeddingFunc {
+		return NewEmbeddingFuncMixedbread(apiKey, EmbeddingModelMixedbreadLargeV1)
+	})
+	registerEmbeddingFuncFromEnv("cohere", "COHERE_API_KEY", func(apiKey string) EmbeddingFunc {
+		return NewEmbeddingFuncCohere(apiKey, EmbeddingModelCohereEnglishV3)
+	})
+	registerEmbeddingFuncFromEnv("voyageai", "VOYAGE_API_KEY", func(apiKey string) EmbeddingFunc {
+		return NewEmbeddingFuncVoyageAI(apiKey, "voyage-3")
+	})
+	RegisterEmbeddingFunc("localai", NewEmbeddingFuncLocalAI("bert-cpp-minilm-v6"))
+}
+
+// registerEmbeddingFuncFromEnv registers an embedding function that's created
+// lazily, so the API key is read from the environment variable on first use and
+// not at program start.
+func registerEmbeddingFuncFromEnv(name, envVar string, newFunc func(apiKey string) EmbeddingFunc) {
+	fn := sync.OnceValue(func() EmbeddingFunc {
+		return newFunc(os.Getenv(envVar))
+	})
+	RegisterEmbeddingFunc(name, func(ctx context.Context, text string) ([]float32, error) {
+		return fn()(ctx, text)
+	})
+}
+
+// RegisterEmbeddingFunc registers an embedding function under the given name,
+// so it can be looked up via [LookupEmbeddingFunc], for example when the
+// embedding function is configured at runtime from a config file.
+// If a function is already registered under the name, it's replaced.
+// It panics if fn is nil.
+//
+// Some embedding functions are registered by default:
+//
+//   - "openai": OpenAI's "text-embedding-3-small" model, with the API key read
+//     from the environment variable "OPENAI_API_KEY" on first use. Same as
+//     [NewEmbeddingFuncDefault].
+//   - "ollama": Ollama's "nomic-embed-text" model, with Ollama running on
+//     "http://localhost:11434".
+//   - "mistral": Mistral's "mistral-embed" model, with the API key read from
+//     "MISTRAL_API_KEY".
+//   - "jina": Jina's "jina-embeddings-v2-base-en" model, with the API key read
+//     from "JINA_API_KEY".
+//   - "mixedbread": Mixedbread's "mxbai-embed-large-v1" model, with the API key
+//     read from "MIXEDBREAD_API_KEY".
+//   - "cohere": Cohere's "embed-english-v3.0" model, with the API key read from
+//     "COHERE_API_KEY".
+//   - "voyageai": Voyage AI's "voyage-3" model, with the API key read from
+//     "VOYAGE_API_KEY".
+//   - "localai": LocalAI's "bert-cpp-minilm-v6" model, with LocalAI running on
+//     "http://localhost:8080".
+//
+// API keys are read from the environment on first use. The other built-in
+// providers aren't registered, because they can't be set up from an API key
+// alone: Azure OpenAI, Cloudflare AI Gateway and Vertex AI need a deployment,
+// account or project, Replicate needs a model version and its input and output
+// format, DeepSeek has no default embedding model, and Upstage uses different
+// models for documents and queries.
+func RegisterEmbeddingFunc(name string, fn EmbeddingFunc) {
+	if fn == nil {
+		panic("chromem: RegisterEmbeddingFunc called with nil func for " + name)
+	}
+
+	embeddingFuncRegistryLock.Lock()
+	defer embeddingFuncRegistryLock.Unlock()
+	embeddingFuncRegistry[name] = fn
+}
+
+// LookupEmbeddingFunc returns the embedding function registered under the given
+// name via [RegisterEmbeddingFunc]. The bool is false if there is none.
+func LookupEmbeddingFunc(name string) (EmbeddingFunc, bool) {
+	embeddingFuncRegistryLock.RLock()
+	defer embeddingFuncRegistryLock.RUnlock()
+	fn, ok := embeddingFuncRegistry[name]
+	return fn, ok
+}
+
+// MustLookupEmbeddingFunc is like [LookupEmbeddingFunc], but panics if there is
+// no embedding function registered under the given name.
+func MustLookupEmbeddingFunc(name string) EmbeddingFunc {
+	fn, ok := LookupEmbeddingFunc(name)
+	if !ok {
+		panic(fmt.Sprintf("chromem: no embedding function registered for %q", name))
+	}
+	return fn
+}
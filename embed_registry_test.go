@@ -0,0 +1,56 @@
+package chromem
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestEmbeddingFuncRegistry(t *testing.T) {
+	vectors := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return vectors, nil
+	}
+
+	t.Run("Register and lookup", func(t *testing.T) {
+		RegisterEmbeddingFunc("test-registry", embeddingFunc)
+
+		fn, ok := LookupEmbeddingFunc("test-registry")
+		if !ok {
+			t.Fatal("expected embedding func to be found")
+		}
+		res, err := fn(context.Background(), "hello world")
+		if err != nil {
+			t.Fatal("expected nil, got", err)
+		}
+		if !slices.Equal(vectors, res) {
+			t.Fatal("expected", vectors, "got", res)
+		}
+
+		if MustLookupEmbeddingFunc("test-registry") == nil {
+			t.Fatal("expected embedding func, got nil")
+		}
+	})
+
+	t.Run("Built-in", func(t *testing.T) {
+		for _, name := range []string{"openai", "ollama", "mistral", "jina", "mixedbread", "cohere", "voyageai", "localai"} {
+			if _, ok := LookupEmbeddingFunc(name); !ok {
+				t.Fatal("expected built-in embedding func to be found:", name)
+			}
+		}
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		fn, ok := LookupEmbeddingFunc("test-registry-not-found")
+		if ok || fn != nil {
+			t.Fatal("expected embedding func to not be found")
+		}
+
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected panic")
+			}
+		}()
+		_ = MustLookupEmbeddingFunc("test-registry-not-found")
+	})
+}
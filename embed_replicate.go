@@ -0,0 +1,184 @@
+package chromem
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const baseURLReplicate = "https://api.replicate.com/v1"
+
+// replicatePollInterval is the interval in which the prediction status is polled.
+// It's a variable so that tests can lower it.
+var replicatePollInterval = time.Second
+
+type replicatePrediction struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	URLs   struct {
+		Get string `json:"get"`
+	} `json:"urls"`
+	Error any `json:"error"`
+}
+
+// NewEmbeddingFuncReplicate returns a function that creates embeddings for a text
+// using a model hosted on Replicate. It creates a prediction, polls it until it
+// succeeded and then extracts the embedding from the prediction.
+//
+//   - apiToken: The Replicate API token.
+//   - modelVersion: The ID of the model version, like
+//     "b6b7585c9640cd7a9572c6e129c9549d79c9c31f0d3fdce7baac7c67ca38f305".
+//   - inputKey: The key of the model input for the text, like "text".
+//   - outputPath: The dot-separated path to the embedding in the prediction JSON,
+//     like "output.embedding". Array elements are accessed by their index, like
+//     "output.0.embedding".
+func NewEmbeddingFuncReplicate(apiToken, modelVersion string, inputKey string, outputPath string) EmbeddingFunc {
+	return newEmbeddingFuncReplicate(baseURLReplicate, apiToken, modelVersion, inputKey, outputPath)
+}
+
+func newEmbeddingFuncReplicate(baseURL, apiToken, modelVersion, inputKey, outputPath string) EmbeddingFunc {
+	// We don't set a default timeout here, although it's usually a good idea.
+	// In our case though, the library user can set the timeout on the context,
+	// and it might have to be a long timeout, depending on the text length and
+	// on the cold start of the model.
+	client := &http.Client{}
+
+	// doRequest sends the request and returns the response body.
+	doRequest := func(ctx context.Context, method, url string, reqBody []byte) ([]byte, error) {
+		// Create the request. Creating it with context is important for a timeout
+		// to be possible, because the client is configured without a timeout.
+		req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(reqBody))
+		if err != nil {
+			return nil, fmt.Errorf("couldn't create request: %w", err)
+		}
+		if reqBody != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set("Authorization", "Bearer "+apiToken)
+
+		// Send the request.
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't send request: %w", err)
+		}
+		defer resp.Body.Close()
+
+		// Check the response status. Creating a prediction returns 201.
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+			return nil, errors.New("error response from the embedding API: " + resp.Status)
+		}
+
+		// Read the response body.
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read response body: %w", err)
+		}
+		return body, nil
+	}
+
+	return func(ctx context.Context, text string) ([]float32, error) {
+		// Prepare the request body.
+		reqBody, err := json.Marshal(map[string]any{
+			"version": modelVersion,
+			"input":   map[string]string{inputKey: text},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("couldn't marshal request body: %w", err)
+		}
+
+		// Create the prediction.
+		body, err := doRequest(ctx, "POST", baseURL+"/predictions", reqBody)
+		if err != nil {
+			return nil, err
+		}
+
+		// Poll the prediction until it's done.
+		for {
+			var prediction replicatePrediction
+			err = json.Unmarshal(body, &prediction)
+			if err != nil {
+				return nil, fmt.Errorf("couldn't unmarshal response body: %w", err)
+			}
+
+			switch prediction.Status {
+			case "succeeded":
+				return replicateEmbedding(body, outputPath)
+			case "failed", "canceled":
+				return nil, fmt.Errorf("prediction %s: %v", prediction.Status, prediction.Error)
+			}
+
+			getURL := prediction.URLs.Get
+			if getURL == "" {
+				if prediction.ID == "" {
+					return nil, errors.New("no prediction URL or ID found in the response")
+				}
+				getURL = baseURL + "/predictions/" + prediction.ID
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(replicatePollInterval):
+			}
+
+			body, err = doRequest(ctx, "GET", getURL, nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// replicateEmbedding extracts the embedding at the dot-separated path from the
+// prediction JSON.
+func replicateEmbedding(prediction []byte, outputPath string) ([]float32, error) {
+	var v any
+	err := json.Unmarshal(prediction, &v)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't unmarshal response body: %w", err)
+	}
+
+	for _, seg := range strings.Split(outputPath, ".") {
+		switch vt := v.(type) {
+		case map[string]any:
+			var ok bool
+			v, ok = vt[seg]
+			if !ok {
+				return nil, fmt.Errorf("output path '%s' not found in the response", outputPath)
+			}
+		case []any:
+			idx, err := strconv.Atoi(seg)
+			if err != nil || idx < 0 || idx >= len(vt) {
+				return nil, fmt.Errorf("output path '%s' not found in the response", outputPath)
+			}
+			v = vt[idx]
+		default:
+			return nil, fmt.Errorf("output path '%s' not found in the response", outputPath)
+		}
+	}
+
+	values, ok := v.([]any)
+	if !ok || len(values) == 0 {
+		return nil, errors.New("no embeddings found in the response")
+	}
+	embedding := make([]float32, len(values))
+	for i, value := range values {
+		f, ok := value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("output at path '%s' is not a list of numbers", outputPath)
+		}
+		embedding[i] = float32(f)
+	}
+
+	if !isNormalized(embedding) {
+		embedding = normalizeVector(embedding)
+	}
+	return embedding, nil
+}
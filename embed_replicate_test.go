@@ -0,0 +1,151 @@
+package chromem
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewEmbeddingFuncReplicate(t *testing.T) {
+	replicatePollInterval = time.Millisecond
+	t.Cleanup(func() { replicatePollInterval = time.Second })
+
+	apiToken := "secret"
+	modelVersion := "abc123"
+	input := "hello world"
+	wantRes := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+
+	var polls atomic.Int32
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Check headers
+		if r.Header.Get("Authorization") != "Bearer "+apiToken {
+			t.Fatal("expected Authorization header", "Bearer "+apiToken, "got", r.Header.Get("Authorization"))
+		}
+
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/predictions":
+			// Check body
+			var body struct {
+				Version string            `json:"version"`
+				Input   map[string]string `json:"input"`
+			}
+			err := json.NewDecoder(r.Body).Decode(&body)
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			if body.Version != modelVersion {
+				t.Fatal("expected version", modelVersion, "got", body.Version)
+			}
+			if body.Input["text"] != input {
+				t.Fatal("expected input", input, "got", body.Input["text"])
+			}
+
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":"p1","status":"starting","urls":{"get":"` + ts.URL + `/predictions/p1"}}`))
+		case r.Method == "GET" && r.URL.Path == "/predictions/p1":
+			// Succeeds on the second poll
+			if polls.Add(1) < 2 {
+				_, _ = w.Write([]byte(`{"id":"p1","status":"processing","urls":{"get":"` + ts.URL + `/predictions/p1"}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"id":"p1","status":"succeeded","output":{"embedding":[-0.1,0.1,0.2]}}`))
+		default:
+			t.Fatal("unexpected request", r.Method, r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	f := newEmbeddingFuncReplicate(ts.URL, apiToken, modelVersion, "text", "output.embedding")
+	res, err := f(context.Background(), input)
+	if err != nil {
+		t.Fatal("expected nil, got", err)
+	}
+	if !slices.Equal(wantRes, res) {
+		t.Fatal("expected res", wantRes, "got", res)
+	}
+	if polls.Load() != 2 {
+		t.Fatal("expected 2 polls, got", polls.Load())
+	}
+}
+
+func TestNewEmbeddingFuncReplicate_Failed(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"p1","status":"failed","error":"out of memory"}`))
+	}))
+	defer ts.Close()
+
+	f := newEmbeddingFuncReplicate(ts.URL, "secret", "abc123", "text", "output")
+	_, err := f(context.Background(), "hello world")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "out of memory") {
+		t.Fatal("expected error to contain the prediction error, got", err)
+	}
+}
+
+func TestReplicateEmbedding(t *testing.T) {
+	wantRes := []float32{-0.40824828, 0.40824828, 0.81649655}
+
+	tt := []struct {
+		name       string
+		prediction string
+		outputPath string
+		wantErr    bool
+	}{
+		{
+			name:       "Output is embedding",
+			prediction: `{"output":[-0.1,0.1,0.2]}`,
+			outputPath: "output",
+		},
+		{
+			name:       "Array index",
+			prediction: `{"output":[{"embedding":[-0.1,0.1,0.2]}]}`,
+			outputPath: "output.0.embedding",
+		},
+		{
+			name:       "Missing key",
+			prediction: `{"output":{"embedding":[-0.1,0.1,0.2]}}`,
+			outputPath: "output.vector",
+			wantErr:    true,
+		},
+		{
+			name:       "Index out of range",
+			prediction: `{"output":[[-0.1,0.1,0.2]]}`,
+			outputPath: "output.1",
+			wantErr:    true,
+		},
+		{
+			name:       "Not a list of numbers",
+			prediction: `{"output":["a","b"]}`,
+			outputPath: "output",
+			wantErr:    true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := replicateEmbedding([]byte(tc.prediction), tc.outputPath)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("expected nil, got", err)
+			}
+			if !slices.Equal(wantRes, res) {
+				t.Fatal("expected res", wantRes, "got", res)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package chromem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// NewDimensionTruncatingEmbeddingFunc returns a function that calls inner and
+// returns only the first dims elements of the embedding. This is valid for
+// models that were trained with Matryoshka Representation Learning, like
+// OpenAI's "text-embedding-3-*" models, and reduces the memory and storage
+// footprint at the cost of some accuracy.
+//
+// The truncated embedding isn't normalized anymore. chromem-go normalizes
+// embeddings when adding documents and querying, but if you need them to be
+// normalized, for example for exporting them, wrap the function with
+// [NewL2NormalizingEmbeddingFunc]:
+//
+//	embeddingFunc := chromem.NewL2NormalizingEmbeddingFunc(
+//		chromem.NewDimensionTruncatingEmbeddingFunc(inner, 256),
+//	)
+//
+// If the embedding has fewer than dims elements, the function returns an error.
+func NewDimensionTruncatingEmbeddingFunc(inner EmbeddingFunc, dims int) EmbeddingFunc {
+	return func(ctx context.Context, text string) ([]float32, error) {
+		if dims <= 0 {
+			return nil, fmt.Errorf("dims must be positive, got %d", dims)
+		}
+
+		v, err := inner(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		if len(v) < dims {
+			return nil, fmt.Errorf("embedding has %d dimensions, can't truncate to %d", len(v), dims)
+		}
+
+		// Copy so that the inner function's slice isn't shared, for example
+		// when it caches embeddings.
+		res := make([]float32, dims)
+		copy(res, v)
+		return res, nil
+	}
+}
+
+// NewL2NormalizingEmbeddingFunc returns a function that calls inner and
+// normalizes the embedding to unit length (L2 norm of 1). Embeddings that are
+// already normalized are returned as is.
+func NewL2NormalizingEmbeddingFunc(inner EmbeddingFunc) EmbeddingFunc {
+	return func(ctx context.Context, text string) ([]float32, error) {
+		v, err := inner(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		if isNormalized(v) {
+			return v, nil
+		}
+		if vectorNorm(v) == 0 {
+			return nil, errors.New("can't normalize zero vector")
+		}
+		return normalizeVector(v), nil
+	}
+}
@@ -0,0 +1,91 @@
+package chromem
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestNewDimensionTruncatingEmbeddingFunc(t *testing.T) {
+	ctx := context.Background()
+	vectors := []float32{0.1, 0.2, 0.3, 0.4, 0.5, 0.6}
+	inner := func(_ context.Context, _ string) ([]float32, error) {
+		return vectors, nil
+	}
+
+	v, err := NewDimensionTruncatingEmbeddingFunc(inner, 4)(ctx, "hello world")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if len(v) != 4 {
+		t.Fatal("expected 4 dimensions, got", len(v))
+	}
+	if !slices.Equal(vectors[:4], v) {
+		t.Fatal("expected", vectors[:4], "got", v)
+	}
+
+	// Truncating to the full dimension is a no-op
+	v, err = NewDimensionTruncatingEmbeddingFunc(inner, len(vectors))(ctx, "hello world")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if !slices.Equal(vectors, v) {
+		t.Fatal("expected", vectors, "got", v)
+	}
+
+	// Errors
+	for _, dims := range []int{0, -1, len(vectors) + 1} {
+		_, err = NewDimensionTruncatingEmbeddingFunc(inner, dims)(ctx, "hello world")
+		if err == nil {
+			t.Fatal("expected error for", dims, "dims, got nil")
+		}
+	}
+	innerErr := errors.New("inner error")
+	failing := func(_ context.Context, _ string) ([]float32, error) {
+		return nil, innerErr
+	}
+	_, err = NewDimensionTruncatingEmbeddingFunc(failing, 4)(ctx, "hello world")
+	if !errors.Is(err, innerErr) {
+		t.Fatal("expected inner error, got", err)
+	}
+}
+
+func TestNewL2NormalizingEmbeddingFunc(t *testing.T) {
+	ctx := context.Background()
+	vectors := []float32{3, 4, 0, 0}
+	inner := func(_ context.Context, _ string) ([]float32, error) {
+		return vectors, nil
+	}
+
+	v, err := NewL2NormalizingEmbeddingFunc(inner)(ctx, "hello world")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if !isNormalized(v) {
+		t.Fatal("expected normalized vector, got", v)
+	}
+	want := []float32{0.6, 0.8, 0, 0}
+	if !slices.Equal(want, v) {
+		t.Fatal("expected", want, "got", v)
+	}
+
+	// Composition: truncated, then normalized
+	v, err = NewL2NormalizingEmbeddingFunc(NewDimensionTruncatingEmbeddingFunc(inner, 1))(ctx, "hello world")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	want = []float32{1}
+	if !slices.Equal(want, v) {
+		t.Fatal("expected", want, "got", v)
+	}
+
+	// Zero vector
+	zero := func(_ context.Context, _ string) ([]float32, error) {
+		return []float32{0, 0}, nil
+	}
+	_, err = NewL2NormalizingEmbeddingFunc(zero)(ctx, "hello world")
+	if err == nil {
+		t.Fatal("expected error for zero vector, got nil")
+	}
+}
@@ -0,0 +1,121 @@
+package chromem
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const baseURLUpstage = "https://api.upstage.ai/v1/solar"
+
+// Upstage embedding models, which can be passed to [NewEmbeddingFuncUpstage].
+const (
+	// For documents (passages) that are added to a collection
+	EmbeddingModelUpstageSolarPassage = "solar-embedding-1-large-passage"
+	// For queries
+	EmbeddingModelUpstageSolarQuery = "solar-embedding-1-large-query"
+)
+
+// upstageErrorResponse is the body of an error response of the Upstage API.
+type upstageErrorResponse struct {
+	Error struct {
+		// Usually a string like "invalid_api_key", but we don't rely on that.
+		Code    json.RawMessage `json:"code"`
+		Message string          `json:"message"`
+	} `json:"error"`
+}
+
+// NewEmbeddingFuncUpstage returns a function that creates embeddings for a text
+// using Upstage's Solar embedding API. Upstage has separate models for documents
+// and queries, see [EmbeddingModelUpstageSolarPassage] and
+// [EmbeddingModelUpstageSolarQuery]. If you use both, you need separate embedding
+// functions for adding documents and for querying, for example by creating the
+// query embedding yourself and using [Collection.QueryEmbedding].
+func NewEmbeddingFuncUpstage(apiKey, model string) EmbeddingFunc {
+	return newEmbeddingFuncUpstage(baseURLUpstage, apiKey, model)
+}
+
+func newEmbeddingFuncUpstage(baseURL, apiKey, model string) EmbeddingFunc {
+	// We don't set a default timeout here, although it's usually a good idea.
+	// In our case though, the library user can set the timeout on the context,
+	// and it might have to be a long timeout, depending on the text length.
+	client := &http.Client{}
+
+	return func(ctx context.Context, text string) ([]float32, error) {
+		// Prepare the request body. Upstage expects the input as single string.
+		reqBody, err := json.Marshal(map[string]string{
+			"input": text,
+			"model": model,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("couldn't marshal request body: %w", err)
+		}
+
+		// Create the request. Creating it with context is important for a timeout
+		// to be possible, because the client is configured without a timeout.
+		req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/embeddings", bytes.NewBuffer(reqBody))
+		if err != nil {
+			return nil, fmt.Errorf("couldn't create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+
+		// Send the request.
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't send request: %w", err)
+		}
+		defer resp.Body.Close()
+
+		// Read the response body.
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read response body: %w", err)
+		}
+
+		// Check the response status. Upstage returns details about the error in
+		// the body, which we include if available.
+		if resp.StatusCode != http.StatusOK {
+			return nil, upstageError(resp.Status, body)
+		}
+
+		// Decode the response body.
+		var embeddingResponse openAIResponse
+		err = json.Unmarshal(body, &embeddingResponse)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't unmarshal response body: %w", err)
+		}
+
+		// Check if the response contains embeddings.
+		if len(embeddingResponse.Data) == 0 || len(embeddingResponse.Data[0].Embedding) == 0 {
+			return nil, errors.New("no embeddings found in the response")
+		}
+
+		v := embeddingResponse.Data[0].Embedding
+		if !isNormalized(v) {
+			v = normalizeVector(v)
+		}
+
+		return v, nil
+	}
+}
+
+// upstageError returns the error for an error response of the Upstage API,
+// including the error code and message from the body if it can be decoded.
+func upstageError(status string, body []byte) error {
+	var errResp upstageErrorResponse
+	err := json.Unmarshal(body, &errResp)
+	if err != nil || (errResp.Error.Message == "" && len(errResp.Error.Code) == 0) {
+		return errors.New("error response from the embedding API: " + status)
+	}
+	code := strings.Trim(string(errResp.Error.Code), `"`)
+	if code == "" || code == "null" {
+		return fmt.Errorf("error response from the embedding API: %s: %s", status, errResp.Error.Message)
+	}
+	return fmt.Errorf("error response from the embedding API: %s: %s (code %s)", status, errResp.Error.Message, code)
+}
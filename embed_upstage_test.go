@@ -0,0 +1,107 @@
+package chromem
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNewEmbeddingFuncUpstage(t *testing.T) {
+	apiKey := "secret"
+	model := EmbeddingModelUpstageSolarPassage
+	input := "hello world"
+	wantRes := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+
+	// Mock server
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Check URL
+		if r.URL.Path != "/embeddings" {
+			t.Fatal("expected URL", "/embeddings", "got", r.URL.Path)
+		}
+		// Check method
+		if r.Method != "POST" {
+			t.Fatal("expected method POST, got", r.Method)
+		}
+		// Check headers
+		if r.Header.Get("Authorization") != "Bearer "+apiKey {
+			t.Fatal("expected Authorization header", "Bearer "+apiKey, "got", r.Header.Get("Authorization"))
+		}
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Fatal("expected Content-Type header", "application/json", "got", r.Header.Get("Content-Type"))
+		}
+		// Check body. The input must be a single string, not an array.
+		var body map[string]any
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			t.Fatal("unexpected error:", err)
+		}
+		if body["input"] != input {
+			t.Fatal("expected input", input, "got", body["input"])
+		}
+		if body["model"] != model {
+			t.Fatal("expected model", model, "got", body["model"])
+		}
+
+		// Write response
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"object":"embedding","index":0,"embedding":[-0.1,0.1,0.2]}],"model":"solar-embedding-1-large-passage"}`))
+	}))
+	defer ts.Close()
+
+	f := newEmbeddingFuncUpstage(ts.URL, apiKey, model)
+	res, err := f(context.Background(), input)
+	if err != nil {
+		t.Fatal("expected nil, got", err)
+	}
+	if !slices.Equal(wantRes, res) {
+		t.Fatal("expected res", wantRes, "got", res)
+	}
+}
+
+func TestNewEmbeddingFuncUpstage_Error(t *testing.T) {
+	tt := []struct {
+		name    string
+		body    string
+		wantErr []string
+	}{
+		{
+			name:    "Upstage error",
+			body:    `{"error":{"message":"Invalid API key","type":"invalid_request_error","param":null,"code":"invalid_api_key"}}`,
+			wantErr: []string{"401", "Invalid API key", "invalid_api_key"},
+		},
+		{
+			name:    "Upstage error without code",
+			body:    `{"error":{"message":"Invalid API key","code":null}}`,
+			wantErr: []string{"401", "Invalid API key"},
+		},
+		{
+			name:    "Other body",
+			body:    `Unauthorized`,
+			wantErr: []string{"401"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer ts.Close()
+
+			f := newEmbeddingFuncUpstage(ts.URL, "wrong", EmbeddingModelUpstageSolarQuery)
+			_, err := f(context.Background(), "hello world")
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			for _, s := range tc.wantErr {
+				if !strings.Contains(err.Error(), s) {
+					t.Fatalf("expected error to contain %q, got %q", s, err)
+				}
+			}
+		})
+	}
+}
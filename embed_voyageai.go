@@ -0,0 +1,92 @@
+// Code generated by gen-embedding-func from voyageai.yaml. DO NOT EDIT.
+
+package chromem
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const baseURLVoyageAI = "https://api.voyageai.com/v1"
+
+// NewEmbeddingFuncVoyageAI returns a function that creates embeddings for a text
+// using the Voyage AI API.
+// See https://docs.voyageai.com/reference/embeddings-api
+func NewEmbeddingFuncVoyageAI(apiKey, model string) EmbeddingFunc {
+	return newEmbeddingFuncVoyageAI(baseURLVoyageAI, apiKey, model)
+}
+
+type voyageAIRequest struct {
+	Input string `json:"input"`
+	Model string `json:"model"`
+}
+
+type voyageAIResponse struct {
+	Data []struct {
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+func newEmbeddingFuncVoyageAI(baseURL, apiKey, model string) EmbeddingFunc {
+	// We don't set a default timeout here, although it's usually a good idea.
+	// In our case though, the library user can set the timeout on the context,
+	// and it might have to be a long timeout, depending on the text length.
+	client := &http.Client{}
+
+	return func(ctx context.Context, text string) ([]float32, error) {
+		// Prepare the request body.
+		reqBody, err := json.Marshal(voyageAIRequest{
+			Input: text,
+			Model: model,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("couldn't marshal request body: %w", err)
+		}
+
+		// Create the request. Creating it with context is important for a timeout
+		// to be possible, because the client is configured without a timeout.
+		req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/embeddings", bytes.NewBuffer(reqBody))
+		if err != nil {
+			return nil, fmt.Errorf("couldn't create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+
+		// Send the request.
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't send request: %w", err)
+		}
+		defer resp.Body.Close()
+
+		// Check the response status.
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.New("error response from the embedding API: " + resp.Status)
+		}
+
+		// Read and decode the response body.
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read response body: %w", err)
+		}
+		var embeddingResponse voyageAIResponse
+		err = json.Unmarshal(body, &embeddingResponse)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't unmarshal response body: %w", err)
+		}
+
+		// Check if the response contains embeddings.
+		if len(embeddingResponse.Data) == 0 || len(embeddingResponse.Data[0].Embedding) == 0 {
+			return nil, errors.New("no embeddings found in the response")
+		}
+
+		v := embeddingResponse.Data[0].Embedding
+		// Voyage AI embeddings are normalized.
+		return v, nil
+	}
+}
@@ -0,0 +1,66 @@
+package chromem
+
+import (
+	"slices"
+	"strings"
+)
+
+// FindByContent returns all documents whose content is exactly the given
+// string, sorted by ID. Unlike [Collection.Query], it doesn't create an
+// embedding, but scans all documents, so it's cheap when you know the exact
+// content you're looking for.
+// The returned documents are copies, so modifying them doesn't affect the
+// collection.
+func (c *Collection) FindByContent(content string) ([]*Document, error) {
+	return c.findByContent(func(docContent string) bool {
+		return docContent == content
+	})
+}
+
+// FindByContentPrefix returns all documents whose content starts with the given
+// prefix, sorted by ID. See [Collection.FindByContent].
+func (c *Collection) FindByContentPrefix(prefix string) ([]*Document, error) {
+	return c.findByContent(func(docContent string) bool {
+		return strings.HasPrefix(docContent, prefix)
+	})
+}
+
+// FindByContentSuffix returns all documents whose content ends with the given
+// suffix, sorted by ID. See [Collection.FindByContent].
+func (c *Collection) FindByContentSuffix(suffix string) ([]*Document, error) {
+	return c.findByContent(func(docContent string) bool {
+		return strings.HasSuffix(docContent, suffix)
+	})
+}
+
+// findByContent returns copies of all documents for whose content match returns
+// true, sorted by ID. Cold documents are loaded from disk.
+func (c *Collection) findByContent(match func(content string) bool) ([]*Document, error) {
+	end, _ := c.beginOp(false)
+	defer end()
+
+	c.documentsLock.RLock()
+	defer c.documentsLock.RUnlock()
+
+	var res []*Document
+	for _, doc := range c.documents {
+		if match(doc.Content) {
+			clone := cloneDocument(doc)
+			res = append(res, &clone)
+		}
+	}
+	for id, doc := range c.cold {
+		if match(doc.Content) {
+			loadedDoc, err := c.loadColdDocument(id)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, loadedDoc)
+		}
+	}
+
+	slices.SortFunc(res, func(a, b *Document) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return res, nil
+}
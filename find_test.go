@@ -0,0 +1,95 @@
+package chromem
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestCollection_FindByContent(t *testing.T) {
+	ctx := context.Background()
+	// The embeddings are passed, so the embedding function must never be called
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		t.Fatal("expected embedding function not to be called")
+		return nil, nil
+	}
+
+	db, err := NewPersistentDB(t.TempDir(), false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c, err := db.CreateCollection("test", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.AddDocuments(ctx, []Document{
+		{ID: "1", Embedding: []float32{1, 0, 0}, Content: "hello world"},
+		{ID: "2", Embedding: []float32{0, 1, 0}, Content: "hello"},
+		{ID: "3", Embedding: []float32{0, 0, 1}, Content: "hello world", Metadata: map[string]string{"foo": "bar"}},
+		{ID: "4", Embedding: []float32{1, 1, 0}, Content: "goodbye world"},
+	}, 1)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	// Cold documents are found as well
+	err = c.MoveToColdStorage([]string{"3"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	ids := func(docs []*Document) []string {
+		var res []string
+		for _, doc := range docs {
+			res = append(res, doc.ID)
+		}
+		return res
+	}
+
+	tt := []struct {
+		name string
+		find func(string) ([]*Document, error)
+		arg  string
+		want []string
+	}{
+		{"Exact", c.FindByContent, "hello world", []string{"1", "3"}},
+		{"Exact no partial match", c.FindByContent, "hello wor", nil},
+		{"Exact no match", c.FindByContent, "foo", nil},
+		{"Prefix", c.FindByContentPrefix, "hello", []string{"1", "2", "3"}},
+		{"Prefix empty", c.FindByContentPrefix, "", []string{"1", "2", "3", "4"}},
+		{"Prefix no match", c.FindByContentPrefix, "world", nil},
+		{"Suffix", c.FindByContentSuffix, "world", []string{"1", "3", "4"}},
+		{"Suffix no match", c.FindByContentSuffix, "hello w", nil},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			docs, err := tc.find(tc.arg)
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+			got := ids(docs)
+			if !slices.Equal(tc.want, got) {
+				t.Fatal("expected", tc.want, "got", got)
+			}
+		})
+	}
+
+	// Found documents are complete, including cold ones
+	docs, err := c.FindByContent("hello world")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if docs[1].Metadata["foo"] != "bar" || !slices.Equal([]float32{0, 0, 1}, docs[1].Embedding) {
+		t.Fatal("expected complete cold document, got", docs[1])
+	}
+
+	// Modifying found documents doesn't affect the collection
+	docs[0].Content = "changed"
+	doc, err := c.GetByID(ctx, "1")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if doc.Content != "hello world" {
+		t.Fatal("expected content \"hello world\", got", doc.Content)
+	}
+}
@@ -0,0 +1,182 @@
+package chromem
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// IDGeneratorFunc generates a document ID for [Collection.AddWithAutoID].
+type IDGeneratorFunc func(content string, metadata map[string]string) string
+
+// NewHashIDGenerator returns an ID generator that uses the hex encoded hash of
+// the content as ID. Adding the same content twice leads to the same ID, so the
+// document is overwritten instead of duplicated. This also means that all
+// documents with empty content get the same ID, so it's not suitable for
+// documents that only have an embedding.
+// The hash function must be linked into the binary, for example by importing
+// "crypto/sha256", otherwise this function panics.
+func NewHashIDGenerator(hash crypto.Hash) IDGeneratorFunc {
+	if !hash.Available() {
+		panic(fmt.Sprintf("hash function %v is not available", hash))
+	}
+	return func(content string, _ map[string]string) string {
+		h := hash.New()
+		h.Write([]byte(content))
+		return hex.EncodeToString(h.Sum(nil))
+	}
+}
+
+// NewUUIDIDGenerator returns an ID generator that generates random UUIDs
+// (version 4), like "6ba7b810-9dad-41d1-80b4-00c04fd430c8".
+func NewUUIDIDGenerator() IDGeneratorFunc {
+	return func(_ string, _ map[string]string) string {
+		var u [16]byte
+		readRandom(u[:])
+		u[6] = (u[6] & 0x0f) | 0x40 // Version 4
+		u[8] = (u[8] & 0x3f) | 0x80 // Variant RFC 4122
+
+		buf := make([]byte, 36)
+		hex.Encode(buf[0:8], u[0:4])
+		buf[8] = '-'
+		hex.Encode(buf[9:13], u[4:6])
+		buf[13] = '-'
+		hex.Encode(buf[14:18], u[6:8])
+		buf[18] = '-'
+		hex.Encode(buf[19:23], u[8:10])
+		buf[23] = '-'
+		hex.Encode(buf[24:], u[10:])
+		return string(buf)
+	}
+}
+
+// crockfordBase32 is the alphabet of Crockford's base32 encoding, which is used
+// by ULIDs.
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULIDIDGenerator returns an ID generator that generates ULIDs, like
+// "01ARZ3NDEKTSV4RRFFQ69G5FAV". ULIDs are sortable by their creation time, so
+// the IDs of documents added later are lexicographically greater. Within the
+// same millisecond, the IDs are monotonically increasing as well.
+func NewULIDIDGenerator() IDGeneratorFunc {
+	var lock sync.Mutex
+	var lastMS uint64
+	var lastEntropy [10]byte
+
+	return func(_ string, _ map[string]string) string {
+		ms := uint64(time.Now().UnixMilli())
+
+		lock.Lock()
+		if ms <= lastMS {
+			// Same millisecond (or the clock went backwards): increment the
+			// entropy of the last ID to stay monotonic.
+			ms = lastMS
+			for i := len(lastEntropy) - 1; i >= 0; i-- {
+				lastEntropy[i]++
+				if lastEntropy[i] != 0 {
+					break
+				}
+			}
+		} else {
+			lastMS = ms
+			readRandom(lastEntropy[:])
+		}
+		entropy := lastEntropy
+		lock.Unlock()
+
+		// 48 bit timestamp and 80 bit entropy, big-endian
+		var u [16]byte
+		binary.BigEndian.PutUint16(u[0:2], uint16(ms>>32))
+		binary.BigEndian.PutUint32(u[2:6], uint32(ms))
+		copy(u[6:], entropy[:])
+
+		// 128 bits are encoded as 26 characters with 5 bits each, with the
+		// first character only encoding the 3 most significant bits.
+		hi := binary.BigEndian.Uint64(u[0:8])
+		lo := binary.BigEndian.Uint64(u[8:16])
+		buf := make([]byte, 26)
+		for i := 25; i >= 0; i-- {
+			buf[i] = crockfordBase32[lo&0x1f]
+			lo = lo>>5 | hi<<59
+			hi >>= 5
+		}
+		return string(buf)
+	}
+}
+
+// readRandom fills b with cryptographically secure random bytes.
+func readRandom(b []byte) {
+	_, err := rand.Read(b)
+	if err != nil {
+		// Only happens if the OS doesn't provide randomness, in which case we
+		// can't generate unique IDs anyway.
+		panic(fmt.Sprintf("couldn't read random bytes: %v", err))
+	}
+}
+
+// defaultIDGenerator is used by [Collection.AddWithAutoID] if no generator was
+// set via [Collection.SetIDGenerator].
+var defaultIDGenerator = NewHashIDGenerator(crypto.SHA256)
+
+// SetIDGenerator sets the function that generates the IDs of documents added
+// with [Collection.AddWithAutoID]. Pass nil to restore the default, which uses
+// the SHA-256 hash of the content, or of the embedding if the content is empty.
+func (c *Collection) SetIDGenerator(fn IDGeneratorFunc) {
+	c.documentsLock.Lock()
+	defer c.documentsLock.Unlock()
+	c.idGenerator = fn
+}
+
+// AddWithAutoID adds a document to the collection, with an ID generated by the
+// collection's ID generator (see [Collection.SetIDGenerator]), and returns the ID.
+// If the embedding is empty, it will be created using the collection's embedding
+// function.
+//
+//   - embedding: The embedding of the document. Optional.
+//   - metadata: The metadata of the document. Optional.
+//   - content: The content of the document. Must not be empty if embedding is.
+func (c *Collection) AddWithAutoID(ctx context.Context, embedding []float32, metadata map[string]string, content string) (string, error) {
+	c.documentsLock.RLock()
+	generate := c.idGenerator
+	c.documentsLock.RUnlock()
+	var id string
+	switch {
+	case generate != nil:
+		id = generate(content, metadata)
+	case content == "" && len(embedding) != 0:
+		// The default generator only hashes the content, so all documents
+		// without content would get the same ID and overwrite each other.
+		id = embeddingHashID(embedding)
+	default:
+		id = defaultIDGenerator(content, metadata)
+	}
+
+	err := c.AddDocument(ctx, Document{
+		ID:        id,
+		Metadata:  metadata,
+		Embedding: embedding,
+		Content:   content,
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// embeddingHashID returns the hex encoded SHA-256 hash of the embedding's
+// elements, as ID of documents without content.
+func embeddingHashID(embedding []float32) string {
+	b := make([]byte, 4*len(embedding))
+	for i, v := range embedding {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(v))
+	}
+	hash := sha256.Sum256(b)
+	return hex.EncodeToString(hash[:])
+}
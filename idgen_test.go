@@ -0,0 +1,141 @@
+package chromem
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/hex"
+	"regexp"
+	"testing"
+)
+
+func TestNewHashIDGenerator(t *testing.T) {
+	content := "hello world"
+	sum := sha256.Sum256([]byte(content))
+	want := hex.EncodeToString(sum[:])
+
+	gen := NewHashIDGenerator(crypto.SHA256)
+	id := gen(content, nil)
+	if id != want {
+		t.Fatal("expected ID", want, "got", id)
+	}
+	// Same content, same ID
+	if gen(content, map[string]string{"foo": "bar"}) != id {
+		t.Fatal("expected same ID for same content")
+	}
+
+	id = NewHashIDGenerator(crypto.SHA512)(content, nil)
+	if !regexp.MustCompile(`^[0-9a-f]{128}$`).MatchString(id) {
+		t.Fatal("expected 128 hex characters, got", id)
+	}
+}
+
+func TestNewUUIDIDGenerator(t *testing.T) {
+	uuidRegex := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	gen := NewUUIDIDGenerator()
+	seen := make(map[string]struct{})
+	for i := 0; i < 100; i++ {
+		id := gen("hello world", nil)
+		if !uuidRegex.MatchString(id) {
+			t.Fatal("expected UUID v4, got", id)
+		}
+		if _, ok := seen[id]; ok {
+			t.Fatal("expected unique IDs, got duplicate", id)
+		}
+		seen[id] = struct{}{}
+	}
+}
+
+func TestNewULIDIDGenerator(t *testing.T) {
+	// Crockford's base32 without I, L, O and U. The first character can only
+	// encode 3 bits.
+	ulidRegex := regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+
+	gen := NewULIDIDGenerator()
+	prev := ""
+	for i := 0; i < 1000; i++ {
+		id := gen("hello world", nil)
+		if !ulidRegex.MatchString(id) {
+			t.Fatal("expected ULID, got", id)
+		}
+		// Also within the same millisecond, IDs must be increasing
+		if id <= prev {
+			t.Fatal("expected increasing IDs, got", prev, "and", id)
+		}
+		prev = id
+	}
+}
+
+func TestCollection_AddWithAutoID(t *testing.T) {
+	ctx := context.Background()
+	vectors := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return vectors, nil
+	}
+
+	db := NewDB()
+	c, err := db.CreateCollection("test", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	// Default: SHA-256 of the content
+	id, err := c.AddWithAutoID(ctx, nil, nil, "hello world")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if want := NewHashIDGenerator(crypto.SHA256)("hello world", nil); id != want {
+		t.Fatal("expected ID", want, "got", id)
+	}
+
+	// Documents with only an embedding get distinct IDs, based on the embedding
+	id1, err := c.AddWithAutoID(ctx, []float32{1, 0, 0}, nil, "")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	id2, err := c.AddWithAutoID(ctx, []float32{0, 1, 0}, nil, "")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if id1 == id2 {
+		t.Fatal("expected different IDs for different embeddings, got", id1)
+	}
+	if c.Count() != 3 {
+		t.Fatal("expected 3 documents, got", c.Count())
+	}
+	id, err = c.AddWithAutoID(ctx, []float32{1, 0, 0}, nil, "")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if id != id1 {
+		t.Fatal("expected ID", id1, "for the same embedding, got", id)
+	}
+	err = c.Delete(ctx, nil, nil, id1, id2)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	// Custom generator
+	c.SetIDGenerator(func(_ string, metadata map[string]string) string {
+		return "doc-" + metadata["n"]
+	})
+	id, err = c.AddWithAutoID(ctx, nil, map[string]string{"n": "2"}, "hallo welt")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if id != "doc-2" {
+		t.Fatal("expected ID doc-2, got", id)
+	}
+	doc, err := c.GetByID(ctx, "doc-2")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if doc.Content != "hallo welt" {
+		t.Fatal("expected content \"hallo welt\", got", doc.Content)
+	}
+	if c.Count() != 2 {
+		t.Fatal("expected 2 documents, got", c.Count())
+	}
+}
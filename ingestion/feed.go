@@ -0,0 +1,350 @@
+// Package ingestion adds documents from external sources, like RSS and Atom
+// feeds, to chromem-go collections. Feeds are parsed with the standard library,
+// so it doesn't add any dependencies.
+package ingestion
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/philippgille/chromem-go"
+)
+
+// Metadata keys of the documents that are added from feeds.
+const (
+	MetadataKeyTitle     = "title"
+	MetadataKeyAuthor    = "author"
+	MetadataKeyPublished = "published"
+	MetadataKeyLink      = "link"
+)
+
+// maxFeedSize is the maximum size of a feed that's read, to protect against
+// huge or endless responses.
+const maxFeedSize = 32 << 20 // 32 MiB
+
+// FeedAddOption is an option for [AddFromFeed].
+type FeedAddOption func(*feedAddOptions)
+
+type feedAddOptions struct {
+	concurrency int
+	metadata    map[string]string
+}
+
+// WithFeedConcurrency sets the number of goroutines that create the embeddings
+// of the new items. The default is the number of CPUs.
+func WithFeedConcurrency(concurrency int) FeedAddOption {
+	return func(o *feedAddOptions) {
+		o.concurrency = concurrency
+	}
+}
+
+// WithFeedMetadata adds the given metadata to each document, for example the
+// name of the feed. The item's own metadata takes precedence.
+func WithFeedMetadata(metadata map[string]string) FeedAddOption {
+	return func(o *feedAddOptions) {
+		o.metadata = metadata
+	}
+}
+
+// rssFeed is an RSS 2.0 feed.
+type rssFeed struct {
+	Items []rssItem `xml:"channel>item"`
+}
+
+type rssItem struct {
+	GUID        string `xml:"guid"`
+	Link        string `xml:"link"`
+	Title       string `xml:"title"`
+	Author      string `xml:"author"`
+	Creator     string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	PubDate     string `xml:"pubDate"`
+	Description string `xml:"description"`
+	Content     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+}
+
+// atomFeed is an Atom 1.0 feed.
+type atomFeed struct {
+	Entries []atomEntry `xml:"http://www.w3.org/2005/Atom entry"`
+}
+
+type atomEntry struct {
+	ID    string `xml:"id"`
+	Title string `xml:"title"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+	Authors []struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+	Summary   string `xml:"summary"`
+	Content   string `xml:"content"`
+}
+
+// feedItem is an item of either feed format.
+type feedItem struct {
+	id        string
+	link      string
+	title     string
+	author    string
+	published string
+	content   string
+}
+
+// AddFromFeed fetches the RSS 2.0 or Atom 1.0 feed at feedURL and adds its items
+// to the collection. It returns the number of added documents.
+//
+// The item's GUID (RSS) or ID (Atom) is used as document ID, falling back to
+// the item's link if it's missing. Items whose ID is already in the collection
+// are skipped, so they aren't embedded again, which makes it cheap to call this
+// periodically. The document content is the item's full content if it's
+// included in the feed, otherwise its description (RSS) or summary (Atom), and
+// as last resort its title. The title, author, publication date and link are
+// stored as metadata with the MetadataKey* keys. The publication date is
+// formatted as [time.RFC3339] if it can be parsed, so that it can be used with
+// [chromem.WithTemporalDecay].
+//
+//   - httpClient: The HTTP client to fetch the feed with. If nil,
+//     [http.DefaultClient] is used.
+func AddFromFeed(ctx context.Context, c *chromem.Collection, feedURL string, httpClient *http.Client, opts ...FeedAddOption) (int, error) {
+	if c == nil {
+		return 0, errors.New("collection is nil")
+	}
+	if feedURL == "" {
+		return 0, errors.New("feed URL is empty")
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	o := feedAddOptions{
+		concurrency: runtime.NumCPU(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.concurrency < 1 {
+		return 0, errors.New("concurrency must be at least 1")
+	}
+
+	body, err := fetchFeed(ctx, httpClient, feedURL)
+	if err != nil {
+		return 0, err
+	}
+	items, err := parseFeed(body)
+	if err != nil {
+		return 0, err
+	}
+
+	var docs []chromem.Document
+	seen := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		if item.id == "" {
+			return 0, fmt.Errorf("feed item %q has neither an ID nor a link", item.title)
+		}
+		if _, ok := seen[item.id]; ok {
+			continue
+		}
+		seen[item.id] = struct{}{}
+		if _, err := c.GetByID(ctx, item.id); err == nil {
+			continue
+		}
+
+		docs = append(docs, chromem.Document{
+			ID:       item.id,
+			Metadata: item.metadata(o.metadata),
+			Content:  item.content,
+		})
+	}
+	if len(docs) == 0 {
+		return 0, nil
+	}
+
+	err = c.AddDocuments(ctx, docs, o.concurrency)
+	if err != nil {
+		return 0, fmt.Errorf("couldn't add documents: %w", err)
+	}
+	return len(docs), nil
+}
+
+// fetchFeed returns the body of the feed at the given URL.
+func fetchFeed(ctx context.Context, httpClient *http.Client, feedURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, text/xml;q=0.8")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't fetch feed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error response from feed server: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedSize))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read feed: %w", err)
+	}
+	return body, nil
+}
+
+// parseFeed parses an RSS 2.0 or Atom 1.0 feed, depending on its root element.
+func parseFeed(body []byte) ([]feedItem, error) {
+	root, err := rootElement(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []feedItem
+	switch {
+	case root.Local == "rss":
+		var feed rssFeed
+		err = xml.Unmarshal(body, &feed)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse RSS feed: %w", err)
+		}
+		for _, it := range feed.Items {
+			items = append(items, it.toFeedItem())
+		}
+	case root.Local == "feed" && root.Space == "http://www.w3.org/2005/Atom":
+		var feed atomFeed
+		err = xml.Unmarshal(body, &feed)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse Atom feed: %w", err)
+		}
+		for _, e := range feed.Entries {
+			items = append(items, e.toFeedItem())
+		}
+	default:
+		return nil, fmt.Errorf("unsupported feed format with root element %q", root.Local)
+	}
+	return items, nil
+}
+
+// rootElement returns the name of the document's root element.
+func rootElement(body []byte) (xml.Name, error) {
+	d := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return xml.Name{}, fmt.Errorf("couldn't parse feed: %w", err)
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			return se.Name, nil
+		}
+	}
+}
+
+func (it rssItem) toFeedItem() feedItem {
+	res := feedItem{
+		id:        strings.TrimSpace(it.GUID),
+		link:      strings.TrimSpace(it.Link),
+		title:     strings.TrimSpace(it.Title),
+		author:    strings.TrimSpace(it.Author),
+		published: normalizeDate(it.PubDate),
+		content:   firstNonEmpty(it.Content, it.Description, it.Title),
+	}
+	if res.id == "" {
+		res.id = res.link
+	}
+	if res.author == "" {
+		res.author = strings.TrimSpace(it.Creator)
+	}
+	return res
+}
+
+func (e atomEntry) toFeedItem() feedItem {
+	res := feedItem{
+		id:        strings.TrimSpace(e.ID),
+		title:     strings.TrimSpace(e.Title),
+		published: normalizeDate(firstNonEmpty(e.Published, e.Updated)),
+		content:   firstNonEmpty(e.Content, e.Summary, e.Title),
+	}
+	for _, l := range e.Links {
+		// The "alternate" link is the one to the item itself, and it's the
+		// default relation.
+		if l.Rel == "" || l.Rel == "alternate" {
+			res.link = strings.TrimSpace(l.Href)
+			break
+		}
+	}
+	if res.id == "" {
+		res.id = res.link
+	}
+	var authors []string
+	for _, a := range e.Authors {
+		if name := strings.TrimSpace(a.Name); name != "" {
+			authors = append(authors, name)
+		}
+	}
+	res.author = strings.Join(authors, ", ")
+	return res
+}
+
+// metadata returns the item's metadata, on top of the given base metadata.
+// Empty values are omitted.
+func (it feedItem) metadata(base map[string]string) map[string]string {
+	res := make(map[string]string, len(base)+4)
+	for k, v := range base {
+		res[k] = v
+	}
+	for k, v := range map[string]string{
+		MetadataKeyTitle:     it.title,
+		MetadataKeyAuthor:    it.author,
+		MetadataKeyPublished: it.published,
+		MetadataKeyLink:      it.link,
+	} {
+		if v != "" {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+// dateLayouts are the layouts of publication dates in feeds. RSS uses RFC 822
+// dates, which are often written with a four digit year, and Atom uses RFC 3339.
+var dateLayouts = []string{
+	time.RFC3339,
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	time.RFC822Z,
+	time.RFC822,
+}
+
+// normalizeDate returns the date formatted as RFC 3339, or as is if it can't be
+// parsed.
+func normalizeDate(s string) string {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format(time.RFC3339)
+		}
+	}
+	return s
+}
+
+// firstNonEmpty returns the first of the strings that isn't empty after
+// trimming whitespace.
+func firstNonEmpty(ss ...string) string {
+	for _, s := range ss {
+		if s = strings.TrimSpace(s); s != "" {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,210 @@
+package ingestion
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/philippgille/chromem-go"
+)
+
+const rssFixture = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel>
+    <title>Example Blog</title>
+    <link>https://example.com/</link>
+    <item>
+      <title>First post</title>
+      <link>https://example.com/first</link>
+      <guid isPermaLink="false">post-1</guid>
+      <author>jane@example.com (Jane Doe)</author>
+      <pubDate>Mon, 02 Sep 2024 10:00:00 +0000</pubDate>
+      <description>Summary of the first post</description>
+      <content:encoded><![CDATA[<p>Full content of the first post</p>]]></content:encoded>
+    </item>
+    <item>
+      <title>Second post</title>
+      <link>https://example.com/second</link>
+      <dc:creator>John Doe</dc:creator>
+      <pubDate>Tue, 3 Sep 2024 12:30:00 GMT</pubDate>
+      <description>Summary of the second post</description>
+    </item>
+  </channel>
+</rss>`
+
+const atomFixture = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Example Feed</title>
+  <entry>
+    <title>Atom entry</title>
+    <link rel="alternate" href="https://example.com/atom-entry"/>
+    <link rel="edit" href="https://example.com/atom-entry/edit"/>
+    <id>urn:uuid:1225c695-cfb8-4ebb-aaaa-80da344efa6a</id>
+    <published>2024-09-04T08:00:00Z</published>
+    <updated>2024-09-05T08:00:00Z</updated>
+    <author><name>Jane Doe</name></author>
+    <summary>Summary of the Atom entry</summary>
+  </entry>
+</feed>`
+
+func TestAddFromFeed_RSS(t *testing.T) {
+	ctx := context.Background()
+
+	var embedCalls int
+	var embedLock sync.Mutex
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		embedLock.Lock()
+		defer embedLock.Unlock()
+		embedCalls++
+		return []float32{-0.40824828, 0.40824828, 0.81649655}, nil
+	}
+	c, err := chromem.NewDB().CreateCollection("test", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/feed.xml" {
+			t.Fatal("unexpected path", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/rss+xml")
+		_, _ = w.Write([]byte(rssFixture))
+	}))
+	defer ts.Close()
+
+	n, err := AddFromFeed(ctx, c, ts.URL+"/feed.xml", ts.Client(), WithFeedMetadata(map[string]string{"feed": "example", "title": "overridden"}))
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if n != 2 {
+		t.Fatal("expected 2 added documents, got", n)
+	}
+	if embedCalls != 2 {
+		t.Fatal("expected 2 embedding calls, got", embedCalls)
+	}
+
+	// GUID as ID and full content
+	doc, err := c.GetByID(ctx, "post-1")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if doc.Content != "<p>Full content of the first post</p>" {
+		t.Fatal("expected full content, got", doc.Content)
+	}
+	want := map[string]string{
+		"feed":               "example",
+		MetadataKeyTitle:     "First post",
+		MetadataKeyAuthor:    "jane@example.com (Jane Doe)",
+		MetadataKeyPublished: "2024-09-02T10:00:00Z",
+		MetadataKeyLink:      "https://example.com/first",
+	}
+	for k, v := range want {
+		if doc.Metadata[k] != v {
+			t.Fatal("expected metadata", k, "to be", v, "got", doc.Metadata[k])
+		}
+	}
+
+	// Link as ID without GUID, description as content and dc:creator as author
+	doc, err = c.GetByID(ctx, "https://example.com/second")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if doc.Content != "Summary of the second post" {
+		t.Fatal("expected description as content, got", doc.Content)
+	}
+	if doc.Metadata[MetadataKeyAuthor] != "John Doe" {
+		t.Fatal("expected author John Doe, got", doc.Metadata[MetadataKeyAuthor])
+	}
+	if doc.Metadata[MetadataKeyPublished] != "2024-09-03T12:30:00Z" {
+		t.Fatal("expected published date 2024-09-03T12:30:00Z, got", doc.Metadata[MetadataKeyPublished])
+	}
+
+	// Adding again skips the existing items without embedding them again
+	n, err = AddFromFeed(ctx, c, ts.URL+"/feed.xml", ts.Client())
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if n != 0 {
+		t.Fatal("expected 0 added documents, got", n)
+	}
+	if embedCalls != 2 {
+		t.Fatal("expected 2 embedding calls, got", embedCalls)
+	}
+	if c.Count() != 2 {
+		t.Fatal("expected 2 documents, got", c.Count())
+	}
+}
+
+func TestAddFromFeed_Atom(t *testing.T) {
+	ctx := context.Background()
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return []float32{-0.40824828, 0.40824828, 0.81649655}, nil
+	}
+	c, err := chromem.NewDB().CreateCollection("test", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/atom+xml")
+		_, _ = w.Write([]byte(atomFixture))
+	}))
+	defer ts.Close()
+
+	n, err := AddFromFeed(ctx, c, ts.URL, nil, WithFeedConcurrency(1))
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if n != 1 {
+		t.Fatal("expected 1 added document, got", n)
+	}
+	doc, err := c.GetByID(ctx, "urn:uuid:1225c695-cfb8-4ebb-aaaa-80da344efa6a")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if doc.Content != "Summary of the Atom entry" {
+		t.Fatal("expected summary as content, got", doc.Content)
+	}
+	want := map[string]string{
+		MetadataKeyTitle:     "Atom entry",
+		MetadataKeyAuthor:    "Jane Doe",
+		MetadataKeyPublished: "2024-09-04T08:00:00Z",
+		MetadataKeyLink:      "https://example.com/atom-entry",
+	}
+	for k, v := range want {
+		if doc.Metadata[k] != v {
+			t.Fatal("expected metadata", k, "to be", v, "got", doc.Metadata[k])
+		}
+	}
+}
+
+func TestAddFromFeed_Errors(t *testing.T) {
+	ctx := context.Background()
+	c, err := chromem.NewDB().CreateCollection("test", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/html":
+			_, _ = w.Write([]byte("<html><body>not a feed</body></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	for _, feedURL := range []string{"", ts.URL + "/html", ts.URL + "/missing"} {
+		_, err = AddFromFeed(ctx, c, feedURL, ts.Client())
+		if err == nil {
+			t.Fatal("expected error for feed URL", feedURL, "got nil")
+		}
+	}
+	_, err = AddFromFeed(ctx, nil, ts.URL, ts.Client())
+	if err == nil {
+		t.Fatal("expected error for nil collection, got nil")
+	}
+}
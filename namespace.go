@@ -0,0 +1,168 @@
+package chromem
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// namespacesDirName is the name of the directory in the DB's persistence
+	// directory that contains the directories of the namespaces.
+	namespacesDirName = "namespaces"
+	// namespaceSeparator separates the namespace from the collection name in the
+	// names of the collections of a namespace.
+	namespaceSeparator = "/"
+)
+
+// ErrNamespaceAlreadyExists is returned by [DB.CreateNamespace] when a
+// namespace with the given name already exists.
+var ErrNamespaceAlreadyExists = errors.New("namespace already exists")
+
+// Namespace groups collections, for example of different tenants, so that
+// collections with the same name in different namespaces are isolated from
+// each other. The collections are regular collections of the DB, with their
+// name prefixed by the namespace name and "/", so they're also listed by
+// [DB.ListCollections]. In a persistent DB, they're stored in the directory
+// "namespaces/{name}" of the DB's persistence directory.
+//
+// Create a namespace with [DB.CreateNamespace] and get an existing one with
+// [DB.GetNamespace].
+type Namespace struct {
+	Name string
+
+	db *DB
+}
+
+// CreateNamespace creates a new namespace with the given name. The name must
+// not be empty or contain path separators. If a namespace with the name already
+// exists, [ErrNamespaceAlreadyExists] is returned.
+func (db *DB) CreateNamespace(name string) (*Namespace, error) {
+	if name == "" {
+		return nil, errors.New("namespace name is empty")
+	}
+	if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("invalid namespace name %q", name)
+	}
+
+	db.collectionsLock.Lock()
+	defer db.collectionsLock.Unlock()
+
+	if _, ok := db.namespaces[name]; ok {
+		return nil, ErrNamespaceAlreadyExists
+	}
+
+	if db.persistDirectory != "" {
+		err := os.MkdirAll(db.namespaceDir(name), 0o700)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't create namespace directory: %w", err)
+		}
+	}
+
+	db.namespaces[name] = struct{}{}
+	return &Namespace{Name: name, db: db}, nil
+}
+
+// GetNamespace returns the namespace with the given name.
+// The returned value is nil if the namespace doesn't exist.
+func (db *DB) GetNamespace(name string) *Namespace {
+	db.collectionsLock.RLock()
+	defer db.collectionsLock.RUnlock()
+
+	if _, ok := db.namespaces[name]; !ok {
+		return nil
+	}
+	return &Namespace{Name: name, db: db}
+}
+
+// loadNamespaces registers the namespaces in the persistence directory and
+// returns the paths of their directories.
+// It's only called from NewPersistentDB, so it doesn't lock.
+func (db *DB) loadNamespaces() ([]string, error) {
+	dirEntries, err := os.ReadDir(filepath.Join(db.persistDirectory, namespacesDirName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("couldn't read namespaces directory: %w", err)
+	}
+	var res []string
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() {
+			continue
+		}
+		db.namespaces[dirEntry.Name()] = struct{}{}
+		res = append(res, db.namespaceDir(dirEntry.Name()))
+	}
+	return res, nil
+}
+
+// collectionParentDir returns the directory in which the directory of the
+// collection with the given name is stored. That's the namespace directory for
+// collections of a namespace, and the persistence directory otherwise.
+// The caller must hold the collectionsLock.
+func (db *DB) collectionParentDir(name string) string {
+	if db.persistDirectory == "" {
+		return ""
+	}
+	if ns, _, ok := strings.Cut(name, namespaceSeparator); ok {
+		if _, ok := db.namespaces[ns]; ok {
+			return db.namespaceDir(ns)
+		}
+	}
+	return db.persistDirectory
+}
+
+// namespaceDir returns the directory of the namespace.
+func (db *DB) namespaceDir(name string) string {
+	return filepath.Join(db.persistDirectory, namespacesDirName, name)
+}
+
+// CreateCollection creates a new collection in the namespace.
+// See [DB.CreateCollection] for details.
+func (ns *Namespace) CreateCollection(name string, metadata map[string]string, embeddingFunc EmbeddingFunc, opts ...CollectionOption) (*Collection, error) {
+	if ns.db.GetNamespace(ns.Name) == nil {
+		return nil, fmt.Errorf("namespace %q doesn't exist", ns.Name)
+	}
+	return ns.db.CreateCollection(ns.collectionName(name), metadata, embeddingFunc, opts...)
+}
+
+// GetCollection returns the collection of the namespace with the given name.
+// The returned value is nil if the collection doesn't exist.
+// See [DB.GetCollection] for details.
+func (ns *Namespace) GetCollection(name string, embeddingFunc EmbeddingFunc) *Collection {
+	return ns.db.GetCollection(ns.collectionName(name), embeddingFunc)
+}
+
+// ListCollections returns all collections of the namespace, keyed by their name
+// without the namespace prefix.
+// See [DB.ListCollections] for details.
+func (ns *Namespace) ListCollections() map[string]*Collection {
+	prefix := ns.Name + namespaceSeparator
+
+	ns.db.collectionsLock.RLock()
+	defer ns.db.collectionsLock.RUnlock()
+
+	res := make(map[string]*Collection)
+	for k, v := range ns.db.collections {
+		if name, ok := strings.CutPrefix(k, prefix); ok {
+			res[name] = v
+		}
+	}
+
+	return res
+}
+
+// DeleteCollection deletes the collection of the namespace with the given name.
+// If the collection doesn't exist, this is a no-op.
+// See [DB.DeleteCollection] for details.
+func (ns *Namespace) DeleteCollection(name string) error {
+	return ns.db.DeleteCollection(ns.collectionName(name))
+}
+
+// collectionName returns the full name of the namespace's collection.
+func (ns *Namespace) collectionName(name string) string {
+	return ns.Name + namespaceSeparator + name
+}
@@ -0,0 +1,213 @@
+package chromem
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNamespace_Isolation(t *testing.T) {
+	ctx := context.Background()
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return []float32{-0.40824828, 0.40824828, 0.81649655}, nil
+	}
+
+	for _, persistent := range []bool{false, true} {
+		name := "In-memory"
+		if persistent {
+			name = "Persistent"
+		}
+		t.Run(name, func(t *testing.T) {
+			db := NewDB()
+			if persistent {
+				var err error
+				db, err = NewPersistentDB(t.TempDir(), false)
+				if err != nil {
+					t.Fatal("expected no error, got", err)
+				}
+			}
+
+			nsA, err := db.CreateNamespace("tenant-a")
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+			nsB, err := db.CreateNamespace("tenant-b")
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+
+			// Collections with the same name in different namespaces are isolated
+			cA, err := nsA.CreateCollection("docs", nil, embeddingFunc)
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+			cB, err := nsB.CreateCollection("docs", nil, embeddingFunc)
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+			if cA == cB {
+				t.Fatal("expected different collections")
+			}
+			err = cA.AddDocument(ctx, Document{ID: "1", Content: "hello from a"})
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+			if cB.Count() != 0 {
+				t.Fatal("expected 0 documents in collection of namespace b, got", cB.Count())
+			}
+			if nsA.GetCollection("docs", nil) != cA {
+				t.Fatal("expected collection of namespace a")
+			}
+			if nsB.GetCollection("docs", nil) != cB {
+				t.Fatal("expected collection of namespace b")
+			}
+			if persistent && cA.persistDirectory == cB.persistDirectory {
+				t.Fatal("expected different persistence directories, got", cA.persistDirectory)
+			}
+
+			// The same name is free at the top level and in namespaces
+			_, err = nsA.CreateCollection("docs", nil, embeddingFunc)
+			if !errors.Is(err, ErrCollectionAlreadyExists) {
+				t.Fatal("expected ErrCollectionAlreadyExists, got", err)
+			}
+			_, err = db.CreateCollection("docs", nil, embeddingFunc)
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+
+			collections := nsA.ListCollections()
+			if len(collections) != 1 || collections["docs"] != cA {
+				t.Fatal("expected only the docs collection of namespace a, got", collections)
+			}
+			if len(db.ListCollections()) != 3 {
+				t.Fatal("expected 3 collections in DB, got", len(db.ListCollections()))
+			}
+
+			// Deleting a collection doesn't affect the one of the other namespace
+			err = nsA.DeleteCollection("docs")
+			if err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+			if nsA.GetCollection("docs", nil) != nil {
+				t.Fatal("expected deleted collection to be missing")
+			}
+			if nsB.GetCollection("docs", nil) != cB {
+				t.Fatal("expected collection of namespace b to still exist")
+			}
+			if persistent {
+				if _, err := os.Stat(cA.persistDirectory); !errors.Is(err, os.ErrNotExist) {
+					t.Fatal("expected directory of deleted collection to be removed, got", err)
+				}
+				if _, err := os.Stat(cB.persistDirectory); err != nil {
+					t.Fatal("expected directory of collection of namespace b to exist, got", err)
+				}
+			}
+		})
+	}
+}
+
+func TestNamespace_Persistence(t *testing.T) {
+	ctx := context.Background()
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return []float32{-0.40824828, 0.40824828, 0.81649655}, nil
+	}
+	dir := t.TempDir()
+
+	db, err := NewPersistentDB(dir, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	ns, err := db.CreateNamespace("tenant")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c, err := ns.CreateCollection("docs", map[string]string{"foo": "bar"}, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.AddDocument(ctx, Document{ID: "1", Content: "hello"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	// An empty namespace is persisted as well
+	_, err = db.CreateNamespace("empty")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	wantDir := filepath.Join(dir, namespacesDirName, "tenant", hash2hex("tenant/docs"))
+	if c.persistDirectory != wantDir {
+		t.Fatal("expected persistence directory", wantDir, "got", c.persistDirectory)
+	}
+	if _, err := os.Stat(wantDir); err != nil {
+		t.Fatal("expected collection directory to exist, got", err)
+	}
+
+	// Reload
+	db, err = NewPersistentDB(dir, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if db.GetNamespace("empty") == nil {
+		t.Fatal("expected empty namespace to be loaded")
+	}
+	ns = db.GetNamespace("tenant")
+	if ns == nil {
+		t.Fatal("expected namespace to be loaded")
+	}
+	c = ns.GetCollection("docs", embeddingFunc)
+	if c == nil {
+		t.Fatal("expected collection to be loaded")
+	}
+	if c.Count() != 1 {
+		t.Fatal("expected 1 document, got", c.Count())
+	}
+	if c.metadata["foo"] != "bar" {
+		t.Fatal("expected metadata foo=bar, got", c.metadata)
+	}
+	if c.persistDirectory != wantDir {
+		t.Fatal("expected persistence directory", wantDir, "got", c.persistDirectory)
+	}
+	if len(db.ListCollections()) != 1 {
+		t.Fatal("expected 1 collection, got", len(db.ListCollections()))
+	}
+}
+
+func TestDB_CreateNamespace_Error(t *testing.T) {
+	db := NewDB()
+	for _, name := range []string{"", ".", "..", "a/b", `a\b`} {
+		_, err := db.CreateNamespace(name)
+		if err == nil {
+			t.Fatalf("expected error for name %q, got nil", name)
+		}
+	}
+
+	_, err := db.CreateNamespace("tenant")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	_, err = db.CreateNamespace("tenant")
+	if !errors.Is(err, ErrNamespaceAlreadyExists) {
+		t.Fatal("expected ErrNamespaceAlreadyExists, got", err)
+	}
+
+	if db.GetNamespace("unknown") != nil {
+		t.Fatal("expected nil for unknown namespace")
+	}
+
+	// After a reset, the namespace doesn't exist anymore
+	ns := db.GetNamespace("tenant")
+	err = db.Reset()
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if db.GetNamespace("tenant") != nil {
+		t.Fatal("expected namespace to be removed by reset")
+	}
+	_, err = ns.CreateCollection("docs", nil, nil)
+	if err == nil {
+		t.Fatal("expected error for removed namespace, got nil")
+	}
+}
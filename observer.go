@@ -0,0 +1,74 @@
+package chromem
+
+import "slices"
+
+// CollectionObserver is notified about lifecycle events of the collections of
+// a DB. See [DB.AddCollectionObserver].
+//
+// The methods are called synchronously while the DB's lock is held, so they
+// must be fast and must not call methods of the DB.
+type CollectionObserver interface {
+	// OnCollectionCreated is called after a collection was created, including
+	// when [DB.UpsertCollection] replaced an existing one.
+	OnCollectionCreated(name string, metadata map[string]string)
+	// OnCollectionDeleted is called after a collection was deleted, including
+	// for each collection when the DB is reset.
+	OnCollectionDeleted(name string)
+	// OnCollectionRenamed is called after a collection was renamed.
+	OnCollectionRenamed(oldName, newName string)
+}
+
+// AddCollectionObserver registers an observer that's notified about created,
+// deleted and renamed collections. Multiple observers can be registered, and
+// they're called in the order of registration.
+func (db *DB) AddCollectionObserver(obs CollectionObserver) {
+	if obs == nil {
+		return
+	}
+
+	db.collectionsLock.Lock()
+	defer db.collectionsLock.Unlock()
+
+	db.observers = append(db.observers, obs)
+}
+
+// RemoveCollectionObserver deregisters an observer that was registered with
+// [DB.AddCollectionObserver]. Observers are compared with ==, so the type of
+// the observer must be comparable, like a pointer. It's a no-op if the observer
+// isn't registered.
+func (db *DB) RemoveCollectionObserver(obs CollectionObserver) {
+	db.collectionsLock.Lock()
+	defer db.collectionsLock.Unlock()
+
+	for i, o := range db.observers {
+		if o == obs {
+			db.observers = slices.Delete(db.observers, i, i+1)
+			return
+		}
+	}
+}
+
+// The notify functions must be called while holding the collections write lock.
+
+func (db *DB) notifyCollectionCreated(name string, metadata map[string]string) {
+	for _, obs := range db.observers {
+		// Copy the metadata, so that observers can't modify the collection's map.
+		m := make(map[string]string, len(metadata))
+		for k, v := range metadata {
+			m[k] = v
+		}
+		obs.OnCollectionCreated(name, m)
+	}
+}
+
+func (db *DB) notifyCollectionDeleted(name string) {
+	for _, obs := range db.observers {
+		obs.OnCollectionDeleted(name)
+	}
+}
+
+func (db *DB) notifyCollectionRenamed(oldName, newName string) {
+	for _, obs := range db.observers {
+		obs.OnCollectionRenamed(oldName, newName)
+	}
+}
@@ -0,0 +1,83 @@
+package chromem
+
+import (
+	"slices"
+	"testing"
+)
+
+type recordingObserver struct {
+	events []string
+}
+
+func (o *recordingObserver) OnCollectionCreated(name string, metadata map[string]string) {
+	o.events = append(o.events, "created "+name+" "+metadata["foo"])
+}
+
+func (o *recordingObserver) OnCollectionDeleted(name string) {
+	o.events = append(o.events, "deleted "+name)
+}
+
+func (o *recordingObserver) OnCollectionRenamed(oldName, newName string) {
+	o.events = append(o.events, "renamed "+oldName+" "+newName)
+}
+
+func TestDB_AddCollectionObserver(t *testing.T) {
+	db := NewDB()
+	obs1 := &recordingObserver{}
+	obs2 := &recordingObserver{}
+	db.AddCollectionObserver(obs1)
+	db.AddCollectionObserver(obs2)
+
+	_, err := db.CreateCollection("test", map[string]string{"foo": "bar"}, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	// Failed operations don't notify
+	_, err = db.CreateCollection("test", nil, nil)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	err = db.RenameCollection("test", "renamed")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = db.DeleteCollection("renamed")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	// Deleting a non-existing collection is a no-op
+	err = db.DeleteCollection("renamed")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	want := []string{"created test bar", "renamed test renamed", "deleted renamed"}
+	if !slices.Equal(want, obs1.events) {
+		t.Fatal("expected events", want, "got", obs1.events)
+	}
+	if !slices.Equal(want, obs2.events) {
+		t.Fatal("expected events", want, "got", obs2.events)
+	}
+
+	// After removing an observer, only the other one is notified
+	db.RemoveCollectionObserver(obs1)
+	_, err = db.CreateCollection("a", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	_, err = db.CreateCollection("b", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = db.Reset()
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if !slices.Equal(want, obs1.events) {
+		t.Fatal("expected events", want, "got", obs1.events)
+	}
+	want = append(want, "created a ", "created b ", "deleted a", "deleted b")
+	if !slices.Equal(want, obs2.events) {
+		t.Fatal("expected events", want, "got", obs2.events)
+	}
+}
@@ -0,0 +1,10 @@
+// For local development against the chromem-go in the parent directory, create a
+// workspace in the repository root with `go work init . ./pdf`.
+module github.com/philippgille/chromem-go/pdf
+
+go 1.21
+
+require (
+	github.com/ledongthuc/pdf v0.0.0-20220302134840-0c2507a12d80
+	github.com/philippgille/chromem-go v0.7.0
+)
@@ -0,0 +1,175 @@
+// Package pdf adds the text of PDF files to chromem-go collections.
+//
+// It's a separate module, so that the main chromem-go module stays free of
+// third-party dependencies.
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+
+	lpdf "github.com/ledongthuc/pdf"
+	"github.com/philippgille/chromem-go"
+)
+
+const (
+	// MetadataKeyPage is the metadata key for the number of the page (starting
+	// at 1) on which a chunk starts.
+	MetadataKeyPage = "page"
+	// MetadataKeyPageEnd is the metadata key for the number of the page on which
+	// a chunk ends. It's only set if the chunk spans multiple pages.
+	MetadataKeyPageEnd = "page_end"
+	// MetadataKeyChunk is the metadata key for the index of the chunk (starting
+	// at 0) within the PDF.
+	MetadataKeyChunk = "chunk"
+)
+
+// Chunker splits text into chunks, each of which is added as separate document.
+// The chunks should be substrings of the text, in the order they appear in the
+// text, so that their page numbers can be determined.
+type Chunker func(text string) []string
+
+// AddFromPDF extracts the text of the PDF read from r page by page and adds it
+// to the collection.
+//
+// The text of all pages is concatenated (separated by a newline) and then split
+// into chunks with the chunker. Each non-empty chunk is added as document with
+// the ID "{id}-{chunkIndex}" and a copy of the given metadata, plus the page
+// number(s) and chunk index (see the MetadataKey* constants). If a chunk isn't
+// found in the text (because the chunker modified it), the page of the previous
+// chunk is used.
+//
+//   - ctx: The context to use for creating the embeddings.
+//   - c: The collection to add the documents to.
+//   - r: The PDF. It's read completely into memory.
+//   - id: The ID of the PDF, used as prefix for the document IDs. Must not be empty.
+//   - metadata: Optional metadata to add to each document.
+//   - chunker: Optional. If nil, the whole text is added as a single document.
+func AddFromPDF(ctx context.Context, c *chromem.Collection, r io.Reader, id string, metadata map[string]string, chunker Chunker) error {
+	if c == nil {
+		return errors.New("collection is nil")
+	}
+	if id == "" {
+		return errors.New("id is empty")
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("couldn't read PDF: %w", err)
+	}
+	pages, err := extractPages(b)
+	if err != nil {
+		return err
+	}
+
+	// Concatenate the pages and remember where each page starts
+	var sb strings.Builder
+	pageStarts := make([]int, len(pages))
+	for i, page := range pages {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		pageStarts[i] = sb.Len()
+		sb.WriteString(page)
+	}
+	text := sb.String()
+
+	var chunks []string
+	if chunker != nil {
+		chunks = chunker(text)
+	} else {
+		chunks = []string{text}
+	}
+
+	docs := make([]chromem.Document, 0, len(chunks))
+	offset := 0
+	startPage, endPage := 1, 1
+	for i, chunk := range chunks {
+		if idx := strings.Index(text[offset:], chunk); idx >= 0 {
+			start := offset + idx
+			startPage = pageAt(pageStarts, start)
+			endPage = pageAt(pageStarts, start+max(len(chunk)-1, 0))
+			// Chunks might overlap, so we only advance to the start of the chunk
+			offset = start
+		}
+		if strings.TrimSpace(chunk) == "" {
+			continue
+		}
+
+		m := make(map[string]string, len(metadata)+3)
+		for k, v := range metadata {
+			m[k] = v
+		}
+		m[MetadataKeyPage] = strconv.Itoa(startPage)
+		if endPage != startPage {
+			m[MetadataKeyPageEnd] = strconv.Itoa(endPage)
+		}
+		m[MetadataKeyChunk] = strconv.Itoa(i)
+
+		docs = append(docs, chromem.Document{
+			ID:       id + "-" + strconv.Itoa(i),
+			Metadata: m,
+			Content:  chunk,
+		})
+	}
+	if len(docs) == 0 {
+		return errors.New("PDF doesn't contain any text")
+	}
+
+	err = c.AddDocuments(ctx, docs, runtime.NumCPU())
+	if err != nil {
+		return fmt.Errorf("couldn't add PDF chunks to collection: %w", err)
+	}
+	return nil
+}
+
+// extractPages returns the plain text of each page of the PDF.
+func extractPages(b []byte) ([]string, error) {
+	r, err := lpdf.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse PDF: %w", err)
+	}
+
+	numPages := r.NumPage()
+	pages := make([]string, 0, numPages)
+	// Cache fonts, so they're not parsed for each page
+	fonts := make(map[string]*lpdf.Font)
+	for i := 1; i <= numPages; i++ {
+		p := r.Page(i)
+		if p.V.IsNull() {
+			pages = append(pages, "")
+			continue
+		}
+		for _, name := range p.Fonts() {
+			if _, ok := fonts[name]; !ok {
+				f := p.Font(name)
+				fonts[name] = &f
+			}
+		}
+		text, err := p.GetPlainText(fonts)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't extract text of page %d: %w", i, err)
+		}
+		pages = append(pages, text)
+	}
+
+	return pages, nil
+}
+
+// pageAt returns the number of the page (starting at 1) that contains the given
+// byte offset of the concatenated text.
+func pageAt(pageStarts []int, offset int) int {
+	page := 1
+	for i, start := range pageStarts {
+		if offset >= start {
+			page = i + 1
+		}
+	}
+	return page
+}
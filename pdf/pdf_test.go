@@ -0,0 +1,116 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/philippgille/chromem-go"
+)
+
+//go:embed testdata/test.pdf
+var testPDF []byte
+
+func TestAddFromPDF(t *testing.T) {
+	ctx := context.Background()
+	vectors := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return vectors, nil
+	}
+
+	db := chromem.NewDB()
+	c, err := db.CreateCollection("test", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	lineChunker := func(text string) []string {
+		return strings.Split(text, "\n")
+	}
+	err = AddFromPDF(ctx, c, bytes.NewReader(testPDF), "doc", map[string]string{"source": "test.pdf"}, lineChunker)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if c.Count() != 2 {
+		t.Fatal("expected 2 documents, got", c.Count())
+	}
+
+	wantContents := map[string]string{
+		"doc-0": "Vector databases store embeddings.",
+		"doc-1": "chromem-go is an embeddable vector database.",
+	}
+	for i, id := range []string{"doc-0", "doc-1"} {
+		doc, err := c.GetByID(ctx, id)
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		if !strings.Contains(doc.Content, wantContents[id]) {
+			t.Fatalf("expected content to contain %q, got %q", wantContents[id], doc.Content)
+		}
+		if doc.Metadata["source"] != "test.pdf" {
+			t.Fatal("expected source metadata \"test.pdf\", got", doc.Metadata["source"])
+		}
+		wantPage := strconv.Itoa(i + 1)
+		if doc.Metadata[MetadataKeyPage] != wantPage {
+			t.Fatal("expected page", wantPage, "got", doc.Metadata[MetadataKeyPage])
+		}
+		if _, ok := doc.Metadata[MetadataKeyPageEnd]; ok {
+			t.Fatal("expected no end page for single page chunk")
+		}
+	}
+}
+
+func TestAddFromPDF_NoChunker(t *testing.T) {
+	ctx := context.Background()
+	vectors := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return vectors, nil
+	}
+
+	db := chromem.NewDB()
+	c, err := db.CreateCollection("test", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	err = AddFromPDF(ctx, c, bytes.NewReader(testPDF), "doc", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	doc, err := c.GetByID(ctx, "doc-0")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	for _, s := range []string{"store embeddings", "embeddable vector database"} {
+		if !strings.Contains(doc.Content, s) {
+			t.Fatalf("expected content to contain %q, got %q", s, doc.Content)
+		}
+	}
+	if doc.Metadata[MetadataKeyPage] != "1" {
+		t.Fatal("expected page 1, got", doc.Metadata[MetadataKeyPage])
+	}
+	if doc.Metadata[MetadataKeyPageEnd] != "2" {
+		t.Fatal("expected end page 2, got", doc.Metadata[MetadataKeyPageEnd])
+	}
+}
+
+func TestAddFromPDF_Errors(t *testing.T) {
+	ctx := context.Background()
+	db := chromem.NewDB()
+	c, err := db.CreateCollection("test", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	err = AddFromPDF(ctx, c, bytes.NewReader(testPDF), "", nil, nil)
+	if err == nil {
+		t.Fatal("expected error for empty ID, got nil")
+	}
+	err = AddFromPDF(ctx, c, strings.NewReader("not a PDF"), "doc", nil, nil)
+	if err == nil {
+		t.Fatal("expected error for invalid PDF, got nil")
+	}
+}
@@ -11,14 +11,31 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
 )
 
 const metadataFileName = "00000000"
 
+// checksumFileExt is the file extension of checksum sidecar files.
+const checksumFileExt = ".crc"
+
+// ErrChecksumMismatch is returned when the checksum of a persisted file doesn't
+// match the checksum in its sidecar file. See [WithChecksumVerification].
+type ErrChecksumMismatch struct {
+	File string
+}
+
+func (e ErrChecksumMismatch) Error() string {
+	return fmt.Sprintf("checksum mismatch for file %q", e.File)
+}
+
 func hash2hex(name string) string {
 	hash := sha256.Sum256([]byte(name))
 	// We encode 4 of the 32 bytes (32 out of 256 bits), so 8 hex characters.
@@ -148,6 +165,127 @@ func persistToWriter(w io.Writer, obj any, compress bool, encryptionKey string)
 	return nil
 }
 
+// persistToFileWithChecksum is like persistToFile without encryption, but also
+// writes a CRC32 checksum of the written bytes to a sidecar file.
+// See [verifyChecksum].
+func persistToFileWithChecksum(filePath string, obj any, compress bool) error {
+	if filePath == "" {
+		return fmt.Errorf("file path is empty")
+	}
+
+	// We need the bytes for the checksum anyway, so we encode into a buffer
+	// instead of directly into the file.
+	buf := &bytes.Buffer{}
+	err := persistToWriter(buf, obj, compress, "")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(filePath), 0o700)
+	if err != nil {
+		return fmt.Errorf("couldn't create parent directories to path: %w", err)
+	}
+	err = os.WriteFile(filePath, buf.Bytes(), 0o600)
+	if err != nil {
+		return fmt.Errorf("couldn't write file: %w", err)
+	}
+
+	checksum := fmt.Sprintf("%08x", crc32.ChecksumIEEE(buf.Bytes()))
+	err = os.WriteFile(checksumPath(filePath), []byte(checksum), 0o600)
+	if err != nil {
+		return fmt.Errorf("couldn't write checksum file: %w", err)
+	}
+
+	return nil
+}
+
+// verifyChecksum compares the CRC32 checksum of the file at the given path with
+// the checksum in its sidecar file. If there's no sidecar file, it's a no-op.
+// On mismatch it returns an [ErrChecksumMismatch].
+func verifyChecksum(filePath string) error {
+	want, err := os.ReadFile(checksumPath(filePath))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("couldn't read checksum file: %w", err)
+	}
+
+	b, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("couldn't read file: %w", err)
+	}
+	got := fmt.Sprintf("%08x", crc32.ChecksumIEEE(b))
+	if got != string(bytes.TrimSpace(want)) {
+		return ErrChecksumMismatch{File: filePath}
+	}
+
+	return nil
+}
+
+// checksumPath returns the path of the checksum sidecar file for the file at
+// the given path, e.g. "/foo/123abc.crc" for "/foo/123abc.gob.gz".
+func checksumPath(filePath string) string {
+	filePath = strings.TrimSuffix(filePath, ".gz")
+	filePath = strings.TrimSuffix(filePath, ".gob")
+	return filePath + checksumFileExt
+}
+
+// metadataCompressionThreshold is the minimum length of a metadata value in
+// bytes for it to be compressed. See [WithMetadataCompression].
+const metadataCompressionThreshold = 256
+
+// compressMetadataValues returns a copy of the metadata with all values that
+// are at least metadataCompressionThreshold bytes long compressed with gzip,
+// and the sorted keys of the compressed values.
+func compressMetadataValues(metadata map[string]string) (map[string]string, []string, error) {
+	res := make(map[string]string, len(metadata))
+	var compressedKeys []string
+	for k, v := range metadata {
+		if len(v) < metadataCompressionThreshold {
+			res[k] = v
+			continue
+		}
+		buf := &bytes.Buffer{}
+		gzw := gzip.NewWriter(buf)
+		_, err := gzw.Write([]byte(v))
+		if err != nil {
+			return nil, nil, fmt.Errorf("couldn't compress value of %q: %w", k, err)
+		}
+		err = gzw.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("couldn't close gzip writer: %w", err)
+		}
+		res[k] = buf.String()
+		compressedKeys = append(compressedKeys, k)
+	}
+	slices.Sort(compressedKeys)
+	return res, compressedKeys, nil
+}
+
+// decompressMetadataValues decompresses the values of the given keys of the
+// metadata in place, which were compressed by compressMetadataValues. The keys
+// are persisted with the document, so other values are never touched, even if
+// they happen to look like gzip data.
+func decompressMetadataValues(metadata map[string]string, keys []string) error {
+	for _, k := range keys {
+		v, ok := metadata[k]
+		if !ok {
+			continue
+		}
+		gzr, err := gzip.NewReader(strings.NewReader(v))
+		if err != nil {
+			return fmt.Errorf("couldn't create gzip reader for value of %q: %w", k, err)
+		}
+		b, err := io.ReadAll(gzr)
+		if err != nil {
+			return fmt.Errorf("couldn't decompress value of %q: %w", k, err)
+		}
+		metadata[k] = string(b)
+	}
+	return nil
+}
+
 // readFromFile reads an object from a file at the given path. The object is deserialized
 // from gob. `obj` must be a pointer to an instantiated object. The file may
 // optionally be compressed as gzip and/or encrypted with AES-GCM. The encryption
@@ -278,3 +416,72 @@ func removeFile(filePath string) error {
 
 	return nil
 }
+
+// moveDir moves the directory from src to dst. It tries to rename it, which is
+// atomic on the same filesystem. If src and dst are on different filesystems,
+// it falls back to copying the directory and then deleting the source.
+func moveDir(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil {
+		return nil
+	} else if !errors.Is(err, syscall.EXDEV) {
+		return fmt.Errorf("couldn't rename directory: %w", err)
+	}
+
+	err = copyDir(src, dst)
+	if err != nil {
+		// Don't leave a partial copy behind
+		_ = os.RemoveAll(dst)
+		return fmt.Errorf("couldn't copy directory: %w", err)
+	}
+	err = os.RemoveAll(src)
+	if err != nil {
+		return fmt.Errorf("couldn't remove source directory after copying: %w", err)
+	}
+	return nil
+}
+
+// copyDir copies the directory from src to dst recursively. dst must not exist.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, relPath)
+
+		if d.IsDir() {
+			return os.Mkdir(target, 0o700)
+		}
+		return copyFile(path, target)
+	})
+}
+
+// copyFile copies the regular file from src to dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return err
+	}
+	// Make sure the data is on disk before the source is deleted
+	err = out.Sync()
+	if err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
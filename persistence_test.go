@@ -236,3 +236,28 @@ func TestPersistenceEncryption(t *testing.T) {
 		})
 	}
 }
+
+func TestCopyDir(t *testing.T) {
+	src := t.TempDir()
+	err := os.MkdirAll(filepath.Join(src, "sub"), 0o700)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = os.WriteFile(filepath.Join(src, "sub", "file.gob"), []byte("hello world"), 0o600)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "copy")
+	err = copyDir(src, dst)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	b, err := os.ReadFile(filepath.Join(dst, "sub", "file.gob"))
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if string(b) != "hello world" {
+		t.Fatal("expected file content \"hello world\", got", string(b))
+	}
+}
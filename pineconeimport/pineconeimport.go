@@ -0,0 +1,239 @@
+// Package pineconeimport imports Pinecone indexes into chromem-go collections,
+// for example when migrating from Pinecone to an embedded vector database.
+// It only uses Pinecone's REST API, so it doesn't add any dependencies.
+package pineconeimport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/philippgille/chromem-go"
+)
+
+// apiVersion is the Pinecone API version that's sent with each request.
+const apiVersion = "2024-07"
+
+// batchSize is the number of vectors that are listed and fetched per request.
+// 100 is the maximum of Pinecone's list endpoint.
+const batchSize = 100
+
+type listResponse struct {
+	Vectors []struct {
+		ID string `json:"id"`
+	} `json:"vectors"`
+	Pagination *struct {
+		Next string `json:"next"`
+	} `json:"pagination"`
+}
+
+type fetchResponse struct {
+	Vectors map[string]struct {
+		ID       string         `json:"id"`
+		Values   []float32      `json:"values"`
+		Metadata map[string]any `json:"metadata"`
+	} `json:"vectors"`
+}
+
+// ImportFromPinecone imports all vectors of a Pinecone index namespace into a
+// chromem-go collection. The collection is created if it doesn't exist yet.
+// The vector IDs are listed with Pinecone's list endpoint, which is only
+// available for serverless indexes, and the vectors are then fetched in batches
+// of 100.
+//
+// Pinecone vectors don't have content, so the documents only have an ID, an
+// embedding and metadata. Metadata values that aren't strings are converted:
+// numbers and booleans to their string representation, and lists of strings to
+// their JSON representation.
+//
+//   - pineconeHost: The host of the Pinecone index, like
+//     "my-index-abc123.svc.us-east-1-aws.pinecone.io". The scheme is optional
+//     and defaults to HTTPS.
+//   - apiKey: The Pinecone API key.
+//   - namespace: The namespace of the index to import. Empty for the default
+//     namespace.
+//   - targetCollectionName: The name of the chromem-go collection.
+//   - embeddingFunc: The embedding function of the collection, used for queries.
+//     It must create embeddings with the same model as the Pinecone vectors.
+func ImportFromPinecone(ctx context.Context, db *chromem.DB, pineconeHost, apiKey, namespace, targetCollectionName string, embeddingFunc chromem.EmbeddingFunc) error {
+	if db == nil {
+		return errors.New("db is nil")
+	}
+	if pineconeHost == "" {
+		return errors.New("host is empty")
+	}
+	if !strings.Contains(pineconeHost, "://") {
+		pineconeHost = "https://" + pineconeHost
+	}
+	client := &pineconeClient{
+		baseURL:    strings.TrimSuffix(pineconeHost, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{},
+	}
+
+	c, err := db.GetOrCreateCollection(targetCollectionName, nil, embeddingFunc)
+	if err != nil {
+		return fmt.Errorf("couldn't get or create collection: %w", err)
+	}
+
+	paginationToken := ""
+	for {
+		ids, next, err := client.list(ctx, namespace, paginationToken)
+		if err != nil {
+			return err
+		}
+		if len(ids) > 0 {
+			docs, err := client.fetch(ctx, namespace, ids)
+			if err != nil {
+				return err
+			}
+			err = c.AddDocuments(ctx, docs, runtime.NumCPU())
+			if err != nil {
+				return fmt.Errorf("couldn't add documents: %w", err)
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		paginationToken = next
+	}
+}
+
+type pineconeClient struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// list returns a page of vector IDs and the token for the next page, which is
+// empty for the last page.
+func (c *pineconeClient) list(ctx context.Context, namespace, paginationToken string) ([]string, string, error) {
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(batchSize))
+	if namespace != "" {
+		query.Set("namespace", namespace)
+	}
+	if paginationToken != "" {
+		query.Set("paginationToken", paginationToken)
+	}
+
+	var resp listResponse
+	err := c.get(ctx, "/vectors/list", query, &resp)
+	if err != nil {
+		return nil, "", fmt.Errorf("couldn't list vectors: %w", err)
+	}
+
+	ids := make([]string, 0, len(resp.Vectors))
+	for _, v := range resp.Vectors {
+		ids = append(ids, v.ID)
+	}
+	next := ""
+	if resp.Pagination != nil {
+		next = resp.Pagination.Next
+	}
+	return ids, next, nil
+}
+
+// fetch returns the vectors with the given IDs as documents.
+func (c *pineconeClient) fetch(ctx context.Context, namespace string, ids []string) ([]chromem.Document, error) {
+	query := url.Values{}
+	query["ids"] = ids
+	if namespace != "" {
+		query.Set("namespace", namespace)
+	}
+
+	var resp fetchResponse
+	err := c.get(ctx, "/vectors/fetch", query, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't fetch vectors: %w", err)
+	}
+
+	docs := make([]chromem.Document, 0, len(resp.Vectors))
+	for id, v := range resp.Vectors {
+		if v.ID != "" {
+			id = v.ID
+		}
+		metadata, err := convertMetadata(v.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't convert metadata of vector '%s': %w", id, err)
+		}
+		docs = append(docs, chromem.Document{
+			ID:        id,
+			Metadata:  metadata,
+			Embedding: v.Values,
+		})
+	}
+	// Deterministic order, which makes errors reproducible
+	sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID })
+	return docs, nil
+}
+
+// get sends a GET request and decodes the JSON response into v.
+func (c *pineconeClient) get(ctx context.Context, path string, query url.Values, v any) error {
+	// Create the request. Creating it with context is important for a timeout
+	// to be possible, because the client is configured without a timeout.
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("couldn't create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Api-Key", c.apiKey)
+	req.Header.Set("X-Pinecone-API-Version", apiVersion)
+
+	// Send the request.
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("couldn't send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check the response status.
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("error response from Pinecone: " + resp.Status)
+	}
+
+	// Read and decode the response body.
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("couldn't read response body: %w", err)
+	}
+	err = json.Unmarshal(body, v)
+	if err != nil {
+		return fmt.Errorf("couldn't unmarshal response body: %w", err)
+	}
+	return nil
+}
+
+// convertMetadata converts Pinecone metadata, which can contain strings, numbers,
+// booleans and lists of strings, to chromem-go metadata.
+func convertMetadata(m map[string]any) (map[string]string, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	res := make(map[string]string, len(m))
+	for k, v := range m {
+		switch vt := v.(type) {
+		case string:
+			res[k] = vt
+		case float64:
+			res[k] = strconv.FormatFloat(vt, 'f', -1, 64)
+		case bool:
+			res[k] = strconv.FormatBool(vt)
+		default:
+			b, err := json.Marshal(vt)
+			if err != nil {
+				return nil, fmt.Errorf("couldn't marshal value of key '%s': %w", k, err)
+			}
+			res[k] = string(b)
+		}
+	}
+	return res, nil
+}
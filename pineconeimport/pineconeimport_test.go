@@ -0,0 +1,143 @@
+package pineconeimport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/philippgille/chromem-go"
+)
+
+func TestImportFromPinecone(t *testing.T) {
+	ctx := context.Background()
+	apiKey := "secret"
+	namespace := "ns"
+
+	// 150 vectors, so that two pages are listed and fetched
+	var allIDs []string
+	for i := 0; i < 150; i++ {
+		allIDs = append(allIDs, fmt.Sprintf("vec-%03d", i))
+	}
+
+	var listCalls, fetchCalls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Check headers
+		if r.Header.Get("Api-Key") != apiKey {
+			t.Fatal("expected Api-Key header", apiKey, "got", r.Header.Get("Api-Key"))
+		}
+		if r.Header.Get("X-Pinecone-API-Version") == "" {
+			t.Fatal("expected X-Pinecone-API-Version header")
+		}
+		q := r.URL.Query()
+		if q.Get("namespace") != namespace {
+			t.Fatal("expected namespace", namespace, "got", q.Get("namespace"))
+		}
+
+		switch r.URL.Path {
+		case "/vectors/list":
+			listCalls++
+			if q.Get("limit") != "100" {
+				t.Fatal("expected limit 100, got", q.Get("limit"))
+			}
+			start := 0
+			if token := q.Get("paginationToken"); token != "" {
+				var err error
+				start, err = strconv.Atoi(token)
+				if err != nil {
+					t.Fatal("unexpected pagination token", token)
+				}
+			}
+			end := min(start+100, len(allIDs))
+			resp := map[string]any{}
+			var vectors []map[string]string
+			for _, id := range allIDs[start:end] {
+				vectors = append(vectors, map[string]string{"id": id})
+			}
+			resp["vectors"] = vectors
+			if end < len(allIDs) {
+				resp["pagination"] = map[string]string{"next": strconv.Itoa(end)}
+			}
+			_ = json.NewEncoder(w).Encode(resp)
+		case "/vectors/fetch":
+			fetchCalls++
+			ids := q["ids"]
+			if len(ids) > 100 {
+				t.Fatal("expected at most 100 IDs, got", len(ids))
+			}
+			vectors := map[string]any{}
+			for _, id := range ids {
+				vectors[id] = map[string]any{
+					"id":     id,
+					"values": []float32{-0.1, 0.1, 0.2},
+					"metadata": map[string]any{
+						"text":  "content of " + id,
+						"year":  2024,
+						"draft": false,
+						"tags":  []string{"a", "b"},
+					},
+				}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"vectors": vectors, "namespace": namespace})
+		default:
+			t.Fatal("unexpected request", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	db := chromem.NewDB()
+	err := ImportFromPinecone(ctx, db, ts.URL, apiKey, namespace, "imported", nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	if listCalls != 2 {
+		t.Fatal("expected 2 list calls, got", listCalls)
+	}
+	if fetchCalls != 2 {
+		t.Fatal("expected 2 fetch calls, got", fetchCalls)
+	}
+	c := db.GetCollection("imported", nil)
+	if c == nil {
+		t.Fatal("expected collection, got nil")
+	}
+	if c.Count() != len(allIDs) {
+		t.Fatal("expected", len(allIDs), "documents, got", c.Count())
+	}
+	doc, err := c.GetByID(ctx, "vec-042")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	wantMetadata := map[string]string{
+		"text":  "content of vec-042",
+		"year":  "2024",
+		"draft": "false",
+		"tags":  `["a","b"]`,
+	}
+	for k, v := range wantMetadata {
+		if doc.Metadata[k] != v {
+			t.Fatal("expected metadata", k, "=", v, "got", doc.Metadata[k])
+		}
+	}
+	wantEmbedding := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+	for i, v := range wantEmbedding {
+		if doc.Embedding[i] != v {
+			t.Fatal("expected embedding", wantEmbedding, "got", doc.Embedding)
+		}
+	}
+}
+
+func TestImportFromPinecone_HTTPError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	err := ImportFromPinecone(context.Background(), chromem.NewDB(), ts.URL, "wrong", "", "imported", nil)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
@@ -0,0 +1,206 @@
+// Package pipeline provides a streaming ingestion pipeline for chromem-go
+// collections. Documents are read from one or more sources, transformed,
+// filtered and then added to a collection.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/philippgille/chromem-go"
+)
+
+// RawDocument is a document as it's read from a source, before it's added to
+// a collection.
+type RawDocument struct {
+	ID       string
+	Metadata map[string]string
+	// Optional. If empty, it will be created by the collection's embedding function.
+	Embedding []float32
+	Content   string
+}
+
+// Source is a source of documents, like files, URLs or databases.
+type Source interface {
+	// Documents returns a channel of documents and a channel of errors.
+	// The source must close the documents channel when it's done. It can send
+	// errors on the error channel, which doesn't need to be closed.
+	// The source should stop sending documents when the context is canceled.
+	Documents(ctx context.Context) (<-chan RawDocument, <-chan error)
+}
+
+// Transformer transforms a document, for example to normalize its content.
+// If it returns an error, the document is counted as failed and not added.
+type Transformer func(RawDocument) (RawDocument, error)
+
+// Filter decides whether a document should be added. If it returns false, the
+// document is counted as filtered and not added.
+type Filter func(RawDocument) bool
+
+// IngestionReport is the result of running an [IngestionPipeline].
+type IngestionReport struct {
+	// The number of documents that were added to the collection.
+	Added int
+	// The number of documents that were filtered out.
+	Filtered int
+	// The number of documents that couldn't be transformed or added.
+	Failed int
+}
+
+// IngestionPipeline reads documents from sources, transforms and filters them
+// and adds them to a collection. Use [NewIngestionPipeline] to create one.
+//
+// The configuration methods return the pipeline, so they can be chained:
+//
+//	report, err := pipeline.NewIngestionPipeline(c).
+//		AddSource(src).
+//		Transform(t).
+//		Filter(f).
+//		Run(ctx)
+//
+// An IngestionPipeline is not safe for concurrent configuration.
+type IngestionPipeline struct {
+	collection   *chromem.Collection
+	sources      []Source
+	transformers []Transformer
+	filters      []Filter
+}
+
+// NewIngestionPipeline creates a new ingestion pipeline that adds documents
+// to the given collection.
+func NewIngestionPipeline(collection *chromem.Collection) *IngestionPipeline {
+	return &IngestionPipeline{
+		collection: collection,
+	}
+}
+
+// AddSource adds a source of documents. Sources are read one after another, in
+// the order they were added.
+func (p *IngestionPipeline) AddSource(src Source) *IngestionPipeline {
+	p.sources = append(p.sources, src)
+	return p
+}
+
+// Transform adds a transformer. Transformers are applied in the order they
+// were added.
+func (p *IngestionPipeline) Transform(t Transformer) *IngestionPipeline {
+	p.transformers = append(p.transformers, t)
+	return p
+}
+
+// Filter adds a filter. Filters are applied after all transformers, and a
+// document is only added if it passes *all* filters.
+func (p *IngestionPipeline) Filter(f Filter) *IngestionPipeline {
+	p.filters = append(p.filters, f)
+	return p
+}
+
+// Run runs the pipeline until all sources are exhausted or the context is
+// canceled. Failing documents don't stop the pipeline, they're counted in the
+// report. Errors from the sources and context cancellation do stop it, and
+// the report contains the counts up to that point.
+func (p *IngestionPipeline) Run(ctx context.Context) (*IngestionReport, error) {
+	if p.collection == nil {
+		return nil, errors.New("collection is nil")
+	}
+
+	report := &IngestionReport{}
+	for i, src := range p.sources {
+		err := p.runSource(ctx, src, report)
+		if err != nil {
+			return report, fmt.Errorf("couldn't ingest documents from source %d: %w", i, err)
+		}
+	}
+
+	return report, nil
+}
+
+// runSource ingests all documents of a single source.
+func (p *IngestionPipeline) runSource(ctx context.Context, src Source, report *IngestionReport) error {
+	// Let the source stop when we return early.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	docChan, errChan := src.Documents(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err, ok := <-errChan:
+			if !ok {
+				// A closed channel would be ready forever, so we stop
+				// selecting it.
+				errChan = nil
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		case rawDoc, ok := <-docChan:
+			if !ok {
+				// The source might have sent an error right before closing
+				// the documents channel.
+				select {
+				case err := <-errChan:
+					return err
+				default:
+					return nil
+				}
+			}
+			p.ingest(ctx, rawDoc, report)
+		}
+	}
+}
+
+// ingest transforms, filters and adds a single document.
+func (p *IngestionPipeline) ingest(ctx context.Context, rawDoc RawDocument, report *IngestionReport) {
+	var err error
+	for _, t := range p.transformers {
+		rawDoc, err = t(rawDoc)
+		if err != nil {
+			report.Failed++
+			return
+		}
+	}
+
+	for _, f := range p.filters {
+		if !f(rawDoc) {
+			report.Filtered++
+			return
+		}
+	}
+
+	err = p.collection.AddDocument(ctx, chromem.Document{
+		ID:        rawDoc.ID,
+		Metadata:  rawDoc.Metadata,
+		Embedding: rawDoc.Embedding,
+		Content:   rawDoc.Content,
+	})
+	if err != nil {
+		report.Failed++
+		return
+	}
+	report.Added++
+}
+
+// SliceSource is a [Source] that reads documents from a slice.
+type SliceSource []RawDocument
+
+// Documents implements [Source].
+func (s SliceSource) Documents(ctx context.Context) (<-chan RawDocument, <-chan error) {
+	docChan := make(chan RawDocument)
+	errChan := make(chan error, 1)
+	go func() {
+		defer close(docChan)
+		for _, doc := range s {
+			select {
+			case <-ctx.Done():
+				errChan <- ctx.Err()
+				return
+			case docChan <- doc:
+			}
+		}
+	}()
+	return docChan, errChan
+}
@@ -0,0 +1,133 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/philippgille/chromem-go"
+)
+
+func TestIngestionPipeline_Run(t *testing.T) {
+	ctx := context.Background()
+	vectors := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return vectors, nil
+	}
+
+	db := chromem.NewDB()
+	c, err := db.CreateCollection("test", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	src := SliceSource{
+		{ID: "1", Content: "  Hello World  "},
+		{ID: "2", Content: "HALLO WELT"},
+		{ID: "3", Content: "   "},                // Empty after normalization -> filtered
+		{ID: "4", Content: "spam: buy now"},      // Filtered
+		{ID: "5", Content: "\xff invalid UTF-8"}, // Fails in transformer
+		{ID: "", Content: "no ID"},               // Fails when adding
+	}
+	normalize := func(doc RawDocument) (RawDocument, error) {
+		if !utf8.ValidString(doc.Content) {
+			return RawDocument{}, errors.New("invalid UTF-8")
+		}
+		doc.Content = strings.ToLower(strings.TrimSpace(doc.Content))
+		return doc, nil
+	}
+	notEmpty := func(doc RawDocument) bool {
+		return doc.Content != ""
+	}
+	notSpam := func(doc RawDocument) bool {
+		return !strings.HasPrefix(doc.Content, "spam")
+	}
+
+	report, err := NewIngestionPipeline(c).
+		AddSource(src).
+		Transform(normalize).
+		Filter(notEmpty).
+		Filter(notSpam).
+		Run(ctx)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	want := IngestionReport{Added: 2, Filtered: 2, Failed: 2}
+	if *report != want {
+		t.Fatalf("expected report %+v, got %+v", want, *report)
+	}
+
+	// Check the normalized documents
+	if c.Count() != 2 {
+		t.Fatal("expected 2 documents, got", c.Count())
+	}
+	for id, content := range map[string]string{"1": "hello world", "2": "hallo welt"} {
+		doc, err := c.GetByID(ctx, id)
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		if doc.Content != content {
+			t.Fatalf("expected content %q, got %q", content, doc.Content)
+		}
+	}
+}
+
+func TestIngestionPipeline_Run_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	db := chromem.NewDB()
+	c, err := db.CreateCollection("test", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	_, err = NewIngestionPipeline(c).AddSource(SliceSource{{ID: "1", Content: "hello world"}}).Run(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatal("expected context.Canceled, got", err)
+	}
+}
+
+// closedErrSource is a [Source] that closes its error channel right away and
+// sends its documents with a delay.
+type closedErrSource []RawDocument
+
+func (s closedErrSource) Documents(_ context.Context) (<-chan RawDocument, <-chan error) {
+	docChan := make(chan RawDocument)
+	errChan := make(chan error)
+	close(errChan)
+	go func() {
+		defer close(docChan)
+		for _, doc := range s {
+			time.Sleep(10 * time.Millisecond)
+			docChan <- doc
+		}
+	}()
+	return docChan, errChan
+}
+
+func TestIngestionPipeline_Run_ClosedErrorChannel(t *testing.T) {
+	ctx := context.Background()
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return []float32{-0.40824828, 0.40824828, 0.81649655}, nil
+	}
+
+	db := chromem.NewDB()
+	c, err := db.CreateCollection("test", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	src := closedErrSource{{ID: "1", Content: "hello world"}, {ID: "2", Content: "hallo welt"}}
+	report, err := NewIngestionPipeline(c).AddSource(src).Run(ctx)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if report.Added != 2 {
+		t.Fatal("expected 2 added documents, got", report.Added)
+	}
+}
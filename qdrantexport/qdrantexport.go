@@ -0,0 +1,221 @@
+// Package qdrantexport exports chromem-go collections to Qdrant, for example
+// when migrating to a client-server vector database. It only uses Qdrant's REST
+// API, so it doesn't add any dependencies.
+package qdrantexport
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/philippgille/chromem-go"
+)
+
+// Payload keys under which the document ID and content are stored, in addition
+// to the document's metadata. They take precedence over metadata with the same
+// keys.
+const (
+	PayloadKeyDocumentID = "document_id"
+	PayloadKeyContent    = "content"
+)
+
+// upsertBatchSize is the number of points per upsert request. It's a variable
+// so that tests can lower it.
+var upsertBatchSize = 256
+
+type point struct {
+	ID      string            `json:"id"`
+	Vector  []float32         `json:"vector"`
+	Payload map[string]string `json:"payload"`
+}
+
+// ExportToQdrant exports all documents of the collection to a Qdrant collection.
+// If the Qdrant collection doesn't exist, it's created with the vector size of
+// the documents' embeddings and cosine distance.
+//
+// The documents are upserted as points with a UUID that's derived from the
+// document ID, so exporting the collection again updates the existing points
+// instead of duplicating them. The payload consists of the document's metadata
+// plus the document ID and content, see [PayloadKeyDocumentID] and
+// [PayloadKeyContent].
+//
+//   - qdrantURL: The base URL of the Qdrant REST API, like "http://localhost:6333".
+//   - collectionName: The name of the Qdrant collection.
+//   - apiKey: The Qdrant API key. Optional.
+func ExportToQdrant(ctx context.Context, col *chromem.Collection, qdrantURL, collectionName string, apiKey string) error {
+	if col == nil {
+		return errors.New("collection is nil")
+	}
+	if collectionName == "" {
+		return errors.New("collection name is empty")
+	}
+	client := &qdrantClient{
+		baseURL:        strings.TrimSuffix(qdrantURL, "/"),
+		collectionPath: "/collections/" + url.PathEscape(collectionName),
+		apiKey:         apiKey,
+		httpClient:     &http.Client{},
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	created := false
+	batch := make([]point, 0, upsertBatchSize)
+	for doc := range col.DocumentChannel(ctx, upsertBatchSize) {
+		if len(doc.Embedding) == 0 {
+			// Cold documents are streamed without their embeddings
+			d, err := col.GetByID(ctx, doc.ID)
+			if err != nil {
+				return fmt.Errorf("couldn't get document '%s': %w", doc.ID, err)
+			}
+			doc = &d
+		}
+
+		if !created {
+			err := client.ensureCollection(ctx, len(doc.Embedding))
+			if err != nil {
+				return err
+			}
+			created = true
+		}
+
+		batch = append(batch, toPoint(doc))
+		if len(batch) == upsertBatchSize {
+			err := client.upsert(ctx, batch)
+			if err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !created {
+		return errors.New("collection is empty")
+	}
+	if len(batch) > 0 {
+		err := client.upsert(ctx, batch)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// toPoint converts the document to a Qdrant point.
+func toPoint(doc *chromem.Document) point {
+	payload := make(map[string]string, len(doc.Metadata)+2)
+	for k, v := range doc.Metadata {
+		payload[k] = v
+	}
+	payload[PayloadKeyDocumentID] = doc.ID
+	if doc.Content != "" {
+		payload[PayloadKeyContent] = doc.Content
+	}
+	return point{
+		ID:      pointID(doc.ID),
+		Vector:  doc.Embedding,
+		Payload: payload,
+	}
+}
+
+// pointID returns a UUID that's derived from the SHA-256 hash of the document ID.
+// Qdrant only accepts unsigned integers and UUIDs as point IDs.
+func pointID(docID string) string {
+	h := sha256.Sum256([]byte(docID))
+	u := h[:16]
+	u[6] = (u[6] & 0x0f) | 0x80 // Version 8 (custom)
+	u[8] = (u[8] & 0x3f) | 0x80 // Variant RFC 4122
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:])
+}
+
+type qdrantClient struct {
+	baseURL        string
+	collectionPath string
+	apiKey         string
+	httpClient     *http.Client
+}
+
+// ensureCollection creates the collection if it doesn't exist yet.
+func (c *qdrantClient) ensureCollection(ctx context.Context, vectorSize int) error {
+	status, err := c.do(ctx, "GET", c.collectionPath, nil, http.StatusOK, http.StatusNotFound)
+	if err != nil {
+		return fmt.Errorf("couldn't get Qdrant collection: %w", err)
+	}
+	if status == http.StatusOK {
+		return nil
+	}
+
+	reqBody := map[string]any{
+		"vectors": map[string]any{
+			"size":     vectorSize,
+			"distance": "Cosine",
+		},
+	}
+	_, err = c.do(ctx, "PUT", c.collectionPath, reqBody, http.StatusOK)
+	if err != nil {
+		return fmt.Errorf("couldn't create Qdrant collection: %w", err)
+	}
+	return nil
+}
+
+// upsert upserts the points and waits until they're applied.
+func (c *qdrantClient) upsert(ctx context.Context, points []point) error {
+	reqBody := map[string]any{
+		"points": points,
+	}
+	_, err := c.do(ctx, "PUT", c.collectionPath+"/points?wait=true", reqBody, http.StatusOK)
+	if err != nil {
+		return fmt.Errorf("couldn't upsert points: %w", err)
+	}
+	return nil
+}
+
+// do sends the request and returns the response status code, which must be one
+// of the expected ones.
+func (c *qdrantClient) do(ctx context.Context, method, path string, reqBody any, expectedStatus ...int) (int, error) {
+	var body io.Reader
+	if reqBody != nil {
+		b, err := json.Marshal(reqBody)
+		if err != nil {
+			return 0, fmt.Errorf("couldn't marshal request body: %w", err)
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return 0, fmt.Errorf("couldn't create request: %w", err)
+	}
+	if reqBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("api-key", c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("couldn't send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	for _, status := range expectedStatus {
+		if resp.StatusCode == status {
+			// Drain the body so that the connection can be reused
+			_, _ = io.Copy(io.Discard, resp.Body)
+			return resp.StatusCode, nil
+		}
+	}
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return resp.StatusCode, fmt.Errorf("error response from Qdrant: %s: %s", resp.Status, bytes.TrimSpace(respBody))
+}
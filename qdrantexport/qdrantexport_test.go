@@ -0,0 +1,174 @@
+package qdrantexport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/philippgille/chromem-go"
+)
+
+func TestExportToQdrant(t *testing.T) {
+	ctx := context.Background()
+	upsertBatchSize = 2
+	t.Cleanup(func() { upsertBatchSize = 256 })
+
+	db := chromem.NewDB()
+	c, err := db.CreateCollection("test", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	for i := 0; i < 3; i++ {
+		err = c.AddDocument(ctx, chromem.Document{
+			ID:        strconv.Itoa(i),
+			Metadata:  map[string]string{"foo": "bar"},
+			Embedding: []float32{-0.40824828, 0.40824828, 0.81649655},
+			Content:   "hello world",
+		})
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+	}
+
+	var (
+		mu         sync.Mutex
+		created    bool
+		upserts    int
+		points     []point
+		apiKey     = "secret"
+		collection = "my-collection"
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.Header.Get("api-key") != apiKey {
+			t.Fatal("expected api-key header", apiKey, "got", r.Header.Get("api-key"))
+		}
+
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/collections/"+collection:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == "PUT" && r.URL.Path == "/collections/"+collection:
+			var body struct {
+				Vectors struct {
+					Size     int    `json:"size"`
+					Distance string `json:"distance"`
+				} `json:"vectors"`
+			}
+			err := json.NewDecoder(r.Body).Decode(&body)
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			if body.Vectors.Size != 3 {
+				t.Fatal("expected vector size 3, got", body.Vectors.Size)
+			}
+			if body.Vectors.Distance != "Cosine" {
+				t.Fatal("expected distance Cosine, got", body.Vectors.Distance)
+			}
+			created = true
+			_, _ = w.Write([]byte(`{"result":true,"status":"ok"}`))
+		case r.Method == "PUT" && r.URL.Path == "/collections/"+collection+"/points":
+			if !created {
+				t.Fatal("expected collection to be created before upserting")
+			}
+			if r.URL.Query().Get("wait") != "true" {
+				t.Fatal("expected wait=true, got", r.URL.Query().Get("wait"))
+			}
+			var body struct {
+				Points []point `json:"points"`
+			}
+			err := json.NewDecoder(r.Body).Decode(&body)
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			upserts++
+			points = append(points, body.Points...)
+			_, _ = w.Write([]byte(`{"result":{"status":"completed"},"status":"ok"}`))
+		default:
+			t.Fatal("unexpected request", r.Method, r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	err = ExportToQdrant(ctx, c, ts.URL, collection, apiKey)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	if upserts != 2 {
+		t.Fatal("expected 2 upsert requests, got", upserts)
+	}
+	if len(points) != 3 {
+		t.Fatal("expected 3 points, got", len(points))
+	}
+	uuidRegex := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	ids := make(map[string]struct{})
+	for _, p := range points {
+		if !uuidRegex.MatchString(p.ID) {
+			t.Fatal("expected UUID, got", p.ID)
+		}
+		if p.ID != pointID(p.Payload[PayloadKeyDocumentID]) {
+			t.Fatal("expected ID derived from document ID, got", p.ID)
+		}
+		ids[p.ID] = struct{}{}
+		if p.Payload["foo"] != "bar" {
+			t.Fatal("expected payload foo=bar, got", p.Payload)
+		}
+		if p.Payload[PayloadKeyContent] != "hello world" {
+			t.Fatal("expected content in payload, got", p.Payload)
+		}
+		if len(p.Vector) != 3 {
+			t.Fatal("expected vector of size 3, got", p.Vector)
+		}
+	}
+	if len(ids) != 3 {
+		t.Fatal("expected 3 unique IDs, got", len(ids))
+	}
+}
+
+func TestExportToQdrant_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	db := chromem.NewDB()
+	c, err := db.CreateCollection("test", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"status":{"error":"Unauthorized"}}`))
+	}))
+	defer ts.Close()
+
+	// Empty collection
+	err = ExportToQdrant(ctx, c, ts.URL, "test", "")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	// Error response
+	err = c.AddDocument(ctx, chromem.Document{ID: "1", Embedding: []float32{1, 0}})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = ExportToQdrant(ctx, c, ts.URL, "test", "")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestPointID(t *testing.T) {
+	if pointID("foo") != pointID("foo") {
+		t.Fatal("expected same ID for same document ID")
+	}
+	if pointID("foo") == pointID("bar") {
+		t.Fatal("expected different IDs for different document IDs")
+	}
+}
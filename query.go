@@ -5,10 +5,12 @@ import (
 	"container/heap"
 	"context"
 	"fmt"
+	"math"
 	"runtime"
 	"slices"
 	"strings"
 	"sync"
+	"time"
 )
 
 var supportedFilters = []string{"$contains", "$not_contains"}
@@ -85,6 +87,14 @@ func (d *maxDocSims) values() []docSim {
 // filterDocs filters a map of documents by metadata and content.
 // It does this concurrently.
 func filterDocs(docs map[string]*Document, where, whereDocument map[string]string) []*Document {
+	return filterDocsFunc(docs, func(doc *Document) bool {
+		return documentMatchesFilters(doc, where, whereDocument)
+	})
+}
+
+// filterDocsFunc filters a map of documents with the given match function.
+// It does this concurrently, so match must be safe for concurrent use.
+func filterDocsFunc(docs map[string]*Document, match func(*Document) bool) []*Document {
 	filteredDocs := make([]*Document, 0, len(docs))
 	filteredDocsLock := sync.Mutex{}
 
@@ -104,7 +114,7 @@ func filterDocs(docs map[string]*Document, where, whereDocument map[string]strin
 		go func() {
 			defer wg.Done()
 			for doc := range docChan {
-				if documentMatchesFilters(doc, where, whereDocument) {
+				if match(doc) {
 					filteredDocsLock.Lock()
 					filteredDocs = append(filteredDocs, doc)
 					filteredDocsLock.Unlock()
@@ -162,7 +172,10 @@ func documentMatchesFilters(document *Document, where, whereDocument map[string]
 	return true
 }
 
-func getMostSimilarDocs(ctx context.Context, queryVectors, negativeVector []float32, negativeFilterThreshold float32, docs []*Document, n int) ([]docSim, error) {
+// getMostSimilarDocs returns the n documents that are most similar to the query,
+// sorted by similarity (descending). If adjust is not nil, it's called with
+// each document's similarity and the returned value is used instead.
+func getMostSimilarDocs(ctx context.Context, queryVectors, negativeVector []float32, negativeFilterThreshold float32, docs []*Document, n int, adjust func(doc *Document, sim float32) float32) ([]docSim, error) {
 	nMaxDocs := newMaxDocSims(n)
 
 	// Determine concurrency. Use number of docs or CPUs, whichever is smaller.
@@ -230,6 +243,10 @@ func getMostSimilarDocs(ctx context.Context, queryVectors, negativeVector []floa
 					}
 				}
 
+				if adjust != nil {
+					sim = adjust(doc, sim)
+				}
+
 				nMaxDocs.add(docSim{docID: doc.ID, similarity: sim})
 			}
 		}(docs[start:end])
@@ -243,3 +260,32 @@ func getMostSimilarDocs(ctx context.Context, queryVectors, negativeVector []floa
 
 	return nMaxDocs.values(), nil
 }
+
+// temporalDecay decays the similarity of documents based on their age.
+// See [WithTemporalDecay].
+type temporalDecay struct {
+	halfLife     time.Duration
+	timestampKey string
+}
+
+// adjustFunc returns a function that multiplies a document's similarity with
+// its decay factor, using now as reference for the document's age.
+func (td *temporalDecay) adjustFunc(now time.Time) func(doc *Document, sim float32) float32 {
+	return func(doc *Document, sim float32) float32 {
+		v, ok := doc.Metadata[td.timestampKey]
+		if !ok {
+			return sim
+		}
+		ts, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return sim
+		}
+		age := now.Sub(ts)
+		// Documents from the future are treated as brand-new.
+		if age < 0 {
+			age = 0
+		}
+		decay := math.Exp(-math.Ln2 * float64(age) / float64(td.halfLife))
+		return float32(float64(sim) * decay)
+	}
+}
@@ -0,0 +1,120 @@
+package chromem
+
+import (
+	"context"
+	"time"
+)
+
+// searchStreamDebounce is the time [Collection.SearchStream] waits for further
+// requests before running a query.
+var searchStreamDebounce = 50 * time.Millisecond
+
+// SearchRequest is a query request for [Collection.SearchStream].
+type SearchRequest struct {
+	QueryText     string
+	NResults      int
+	Where         map[string]string
+	WhereDocument map[string]string
+}
+
+// SearchResponse is the response to a [SearchRequest].
+type SearchResponse struct {
+	// The request that the results belong to.
+	Request SearchRequest
+	Results []Result
+	// Set if the query failed, for example because the embedding couldn't be
+	// created. The stream continues with the next request.
+	Err error
+}
+
+// searchResult is the result of a single query run by SearchStream.
+type searchResult struct {
+	gen  uint64
+	resp SearchResponse
+}
+
+// SearchStream runs the queries that are sent on the requests channel and sends
+// their results on the responses channel. It's meant for conversational search
+// UIs where the client refines the query while typing, for example behind a
+// bidirectional gRPC or WebSocket stream.
+//
+// Rapid requests are debounced: a query only runs after no new request arrived
+// for 50 ms, and a new query cancels the one that's still in flight. Results of
+// superseded queries are never sent, so a response always belongs to the latest
+// query at the time it was run.
+//
+// SearchStream returns nil after the requests channel is closed and the last
+// query finished. It returns the context's error when the context is canceled.
+// It doesn't close the responses channel.
+func (c *Collection) SearchStream(ctx context.Context, requests <-chan SearchRequest, responses chan<- SearchResponse) error {
+	timer := time.NewTimer(searchStreamDebounce)
+	if !timer.Stop() {
+		<-timer.C
+	}
+	defer timer.Stop()
+
+	var (
+		pending   *SearchRequest
+		gen       uint64
+		inFlight  bool
+		cancelRun = func() {}
+	)
+	// Results of all runs, including superseded ones, which are discarded.
+	results := make(chan searchResult)
+	defer func() { cancelRun() }()
+
+	for requests != nil || pending != nil || inFlight {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case req, ok := <-requests:
+			if !ok {
+				requests = nil
+				continue
+			}
+			pending = &req
+			// Restart the debounce timer
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(searchStreamDebounce)
+		case <-timer.C:
+			if pending == nil {
+				continue
+			}
+			// Cancel the query that's in flight, if any
+			cancelRun()
+			runCtx, cancel := context.WithCancel(ctx)
+			cancelRun = cancel
+			gen++
+			go c.runSearch(runCtx, gen, *pending, results)
+			pending = nil
+			inFlight = true
+		case res := <-results:
+			if res.gen != gen {
+				// Superseded
+				continue
+			}
+			inFlight = false
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case responses <- res.resp:
+			}
+		}
+	}
+
+	return nil
+}
+
+// runSearch runs the query and sends the result, unless the context is canceled.
+func (c *Collection) runSearch(ctx context.Context, gen uint64, req SearchRequest, results chan<- searchResult) {
+	res, err := c.Query(ctx, req.QueryText, req.NResults, req.Where, req.WhereDocument)
+	select {
+	case <-ctx.Done():
+	case results <- searchResult{gen: gen, resp: SearchResponse{Request: req, Results: res, Err: err}}:
+	}
+}
@@ -0,0 +1,129 @@
+package chromem
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCollection_SearchStream(t *testing.T) {
+	ctx := context.Background()
+
+	firstStarted := make(chan struct{})
+	firstCanceled := make(chan struct{})
+	embed := func(ctx context.Context, text string) ([]float32, error) {
+		switch text {
+		case "first":
+			// Blocks until the query is canceled by the second one
+			close(firstStarted)
+			<-ctx.Done()
+			close(firstCanceled)
+			return nil, ctx.Err()
+		case "second":
+			return []float32{0, 1, 0}, nil
+		default:
+			return []float32{1, 0, 0}, nil
+		}
+	}
+	c, err := NewDB().CreateCollection("test", nil, embed)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.AddDocuments(ctx, []Document{
+		{ID: "1", Embedding: []float32{1, 0, 0}, Content: "foo"},
+		{ID: "2", Embedding: []float32{0, 1, 0}, Content: "bar"},
+	}, 1)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	t.Run("debounce", func(t *testing.T) {
+		requests := make(chan SearchRequest)
+		responses := make(chan SearchResponse, 10)
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- c.SearchStream(ctx, requests, responses)
+		}()
+
+		// Two rapid queries. Only the second one must be run.
+		requests <- SearchRequest{QueryText: "foo", NResults: 1}
+		requests <- SearchRequest{QueryText: "second", NResults: 1}
+		close(requests)
+
+		err := <-errChan
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		close(responses)
+		var got []SearchResponse
+		for resp := range responses {
+			got = append(got, resp)
+		}
+		if len(got) != 1 {
+			t.Fatal("expected 1 response, got", len(got))
+		}
+		if got[0].Request.QueryText != "second" {
+			t.Fatal("expected response for \"second\", got", got[0].Request.QueryText)
+		}
+		if got[0].Err != nil {
+			t.Fatal("expected no error, got", got[0].Err)
+		}
+		if len(got[0].Results) != 1 || got[0].Results[0].ID != "2" {
+			t.Fatal("expected result with ID \"2\", got", got[0].Results)
+		}
+	})
+
+	t.Run("cancel in-flight", func(t *testing.T) {
+		requests := make(chan SearchRequest)
+		responses := make(chan SearchResponse, 10)
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- c.SearchStream(ctx, requests, responses)
+		}()
+
+		requests <- SearchRequest{QueryText: "first", NResults: 1}
+		select {
+		case <-firstStarted:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected first query to start")
+		}
+		requests <- SearchRequest{QueryText: "second", NResults: 1}
+		close(requests)
+
+		select {
+		case <-firstCanceled:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected first query to be canceled")
+		}
+		err := <-errChan
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		close(responses)
+		var got []SearchResponse
+		for resp := range responses {
+			got = append(got, resp)
+		}
+		if len(got) != 1 {
+			t.Fatal("expected 1 response, got", len(got))
+		}
+		if got[0].Request.QueryText != "second" {
+			t.Fatal("expected response for \"second\", got", got[0].Request.QueryText)
+		}
+	})
+
+	t.Run("context canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		requests := make(chan SearchRequest)
+		responses := make(chan SearchResponse)
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- c.SearchStream(ctx, requests, responses)
+		}()
+		cancel()
+		err := <-errChan
+		if err != context.Canceled {
+			t.Fatal("expected context.Canceled, got", err)
+		}
+	})
+}
@@ -0,0 +1,180 @@
+package chromem
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxShards is the maximum number of shards, because the shard index is derived
+// from the first byte of the document ID's hash.
+const maxShards = 256
+
+// shardDirPrefix is the prefix of the shard subdirectories of a collection
+// directory, which are named like "shard-000".
+const shardDirPrefix = "shard-"
+
+// EnableSharding distributes the document files of the collection across the
+// given number of subdirectories ("shards") of the collection directory, which
+// is more efficient for the filesystem than millions of files in a single
+// directory. A document is placed in the shard with the index
+// SHA256(docID)[0] % shards.
+//
+// Existing document files are moved to their shard. Calling it again with a
+// different number redistributes the documents. The number of shards is stored
+// in the collection metadata before the documents are moved, so if the
+// redistribution is interrupted, for example by a crash, [NewPersistentDB]
+// completes it. Cold documents (see [Collection.MoveToColdStorage]) are not
+// sharded.
+//
+// Only works for persistent collections. Concurrent writes to the collection
+// wait until the documents are redistributed.
+//
+//   - shards: The number of shards, between 1 and 256.
+func (c *Collection) EnableSharding(shards int) error {
+	if c.persistDirectory == "" {
+		return errors.New("collection is not persistent")
+	}
+	if shards < 1 || shards > maxShards {
+		return fmt.Errorf("shards must be between 1 and %d, got %d", maxShards, shards)
+	}
+
+	end, err := c.beginOp(true)
+	if err != nil {
+		return err
+	}
+	defer end()
+
+	c.documentsLock.Lock()
+	defer c.documentsLock.Unlock()
+
+	// The new number of shards is persisted first, so that the documents are
+	// looked up in their new shard after loading, and the redistribution can
+	// be completed then.
+	prevShards := c.shards
+	c.shards = shards
+	err = c.persistMetadata()
+	if err != nil {
+		c.shards = prevShards
+		return fmt.Errorf("couldn't persist collection metadata: %w", err)
+	}
+
+	return c.moveToShards()
+}
+
+// moveToShards moves the document files to the shards of the collection's
+// number of shards, from the collection directory and any shard directory, and
+// removes the shard directories that aren't used anymore.
+// The caller must hold the documents write lock.
+func (c *Collection) moveToShards() error {
+	shards := c.shards
+	for i := 0; i < shards; i++ {
+		err := os.MkdirAll(c.shardDir(i), 0o700)
+		if err != nil {
+			return fmt.Errorf("couldn't create shard directory: %w", err)
+		}
+	}
+
+	// Move the document files, which can currently be in the collection
+	// directory or any shard directory.
+	srcDirs := []string{c.persistDirectory}
+	oldShardDirs, err := shardDirs(c.persistDirectory)
+	if err != nil {
+		return err
+	}
+	srcDirs = append(srcDirs, oldShardDirs...)
+	for _, srcDir := range srcDirs {
+		dirEntries, err := os.ReadDir(srcDir)
+		if err != nil {
+			return fmt.Errorf("couldn't read directory %q: %w", srcDir, err)
+		}
+		for _, dirEntry := range dirEntries {
+			if dirEntry.IsDir() {
+				continue
+			}
+			name := dirEntry.Name()
+			// The document file names start with the hex encoded hash of the
+			// document ID, so the shard can be determined without reading the
+			// file. This also moves checksum files.
+			hashHex, _, _ := strings.Cut(name, ".")
+			if srcDir == c.persistDirectory && hashHex == metadataFileName {
+				continue
+			}
+			hash, err := hex.DecodeString(hashHex)
+			if err != nil || len(hash) == 0 {
+				// Might be a file that the user has placed
+				continue
+			}
+			dstDir := c.shardDir(int(hash[0]) % shards)
+			if dstDir == srcDir {
+				continue
+			}
+			err = os.Rename(filepath.Join(srcDir, name), filepath.Join(dstDir, name))
+			if err != nil {
+				return fmt.Errorf("couldn't move document file to shard: %w", err)
+			}
+		}
+	}
+
+	// Remove the shard directories that aren't used anymore
+	for _, dir := range oldShardDirs {
+		idx, _ := parseShardDirName(filepath.Base(dir))
+		if idx >= shards {
+			err := os.Remove(dir)
+			if err != nil {
+				return fmt.Errorf("couldn't remove unused shard directory: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// shardDir returns the path of the shard directory with the given index.
+func (c *Collection) shardDir(idx int) string {
+	return filepath.Join(c.persistDirectory, fmt.Sprintf("%s%03d", shardDirPrefix, idx))
+}
+
+// shardIndex returns the index of the shard that the document belongs to.
+func shardIndex(docID string, shards int) int {
+	hash := sha256.Sum256([]byte(docID))
+	return int(hash[0]) % shards
+}
+
+// parseShardDirName returns the index of the shard directory with the given
+// name, or false if it's not a shard directory.
+func parseShardDirName(name string) (int, bool) {
+	idxStr, ok := strings.CutPrefix(name, shardDirPrefix)
+	if !ok || len(idxStr) != 3 {
+		return 0, false
+	}
+	idx, err := strconv.Atoi(idxStr)
+	if err != nil || idx < 0 || idx >= maxShards {
+		return 0, false
+	}
+	return idx, true
+}
+
+// shardDirs returns the paths of the shard directories in the collection
+// directory.
+func shardDirs(collectionPath string) ([]string, error) {
+	dirEntries, err := os.ReadDir(collectionPath)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read collection directory: %w", err)
+	}
+	var res []string
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() {
+			continue
+		}
+		if _, ok := parseShardDirName(dirEntry.Name()); ok {
+			res = append(res, filepath.Join(collectionPath, dirEntry.Name()))
+		}
+	}
+	return res, nil
+}
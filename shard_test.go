@@ -0,0 +1,247 @@
+package chromem
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCollection_EnableSharding(t *testing.T) {
+	ctx := context.Background()
+	name := "test"
+	vectors := []float32{-0.40824828, 0.40824828, 0.81649655} // normalized version of `{-0.1, 0.1, 0.2}`
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return vectors, nil
+	}
+
+	tempDir := t.TempDir()
+	db, err := NewPersistentDB(tempDir, false, WithChecksumVerification())
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c, err := db.CreateCollection(name, nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	var ids []string
+	for i := 0; i < 50; i++ {
+		id := strconv.Itoa(i)
+		ids = append(ids, id)
+		err = c.AddDocument(ctx, Document{ID: id, Content: "doc " + id})
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+	}
+
+	// checkPlacement checks that the documents (and their checksums) are in the
+	// correct shards, and that only the metadata file is left in the collection
+	// directory.
+	checkPlacement := func(t *testing.T, c *Collection, shards int) {
+		t.Helper()
+		for _, id := range ids {
+			wantDir := filepath.Join(c.persistDirectory, fmt.Sprintf("shard-%03d", shardIndex(id, shards)))
+			docPath := c.getDocPath(id)
+			if filepath.Dir(docPath) != wantDir {
+				t.Fatal("expected document in", wantDir, "got", docPath)
+			}
+			if _, err := os.Stat(docPath); err != nil {
+				t.Fatal("expected document file to exist, got", err)
+			}
+			if _, err := os.Stat(checksumPath(docPath)); err != nil {
+				t.Fatal("expected checksum file to exist, got", err)
+			}
+		}
+		dirEntries, err := os.ReadDir(c.persistDirectory)
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		var shardDirCount int
+		for _, dirEntry := range dirEntries {
+			if dirEntry.IsDir() {
+				if !strings.HasPrefix(dirEntry.Name(), "shard-") {
+					t.Fatal("unexpected directory", dirEntry.Name())
+				}
+				shardDirCount++
+				continue
+			}
+			if dirEntry.Name() != metadataFileName+".gob" {
+				t.Fatal("unexpected file in collection directory", dirEntry.Name())
+			}
+		}
+		if shardDirCount != shards {
+			t.Fatal("expected", shards, "shard directories, got", shardDirCount)
+		}
+	}
+
+	err = c.EnableSharding(4)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	checkPlacement(t, c, 4)
+
+	// New documents are placed in their shard
+	ids = append(ids, "new")
+	err = c.AddDocument(ctx, Document{ID: "new", Content: "new doc"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	checkPlacement(t, c, 4)
+
+	// Deleted documents are removed from their shard
+	docPath := c.getDocPath("new")
+	ids = ids[:len(ids)-1]
+	err = c.Delete(ctx, nil, nil, "new")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if _, err := os.Stat(docPath); !os.IsNotExist(err) {
+		t.Fatal("expected document file to be removed, got", err)
+	}
+
+	// The number of shards is read from the metadata when loading
+	db2, err := NewPersistentDB(tempDir, false, WithChecksumVerification())
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c2 := db2.GetCollection(name, embeddingFunc)
+	if c2 == nil {
+		t.Fatal("expected collection, got nil")
+	}
+	if c2.shards != 4 {
+		t.Fatal("expected 4 shards, got", c2.shards)
+	}
+	if c2.Count() != len(ids) {
+		t.Fatal("expected", len(ids), "documents, got", c2.Count())
+	}
+	doc, err := c2.GetByID(ctx, "42")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if doc.Content != "doc 42" {
+		t.Fatal("expected content \"doc 42\", got", doc.Content)
+	}
+
+	// Redistribution
+	err = c2.EnableSharding(2)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	checkPlacement(t, c2, 2)
+	db3, err := NewPersistentDB(tempDir, false, WithChecksumVerification())
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c3 := db3.GetCollection(name, embeddingFunc)
+	if c3.shards != 2 {
+		t.Fatal("expected 2 shards, got", c3.shards)
+	}
+	if c3.Count() != len(ids) {
+		t.Fatal("expected", len(ids), "documents, got", c3.Count())
+	}
+
+	// Interrupted redistribution from 4 to 2 shards, after the new number was
+	// persisted but before the documents were moved. A left-over empty shard
+	// directory with a higher index doesn't affect the number of shards.
+	err = c3.EnableSharding(4)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c3.shards = 2
+	err = c3.persistMetadata()
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = os.Mkdir(c3.shardDir(7), 0o700)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	db4, err := NewPersistentDB(tempDir, false, WithChecksumVerification())
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c4 := db4.GetCollection(name, embeddingFunc)
+	if c4.shards != 2 {
+		t.Fatal("expected 2 shards, got", c4.shards)
+	}
+	if c4.Count() != len(ids) {
+		t.Fatal("expected", len(ids), "documents, got", c4.Count())
+	}
+	checkPlacement(t, c4, 2)
+}
+
+func TestCollection_EnableSharding_Errors(t *testing.T) {
+	c, err := NewDB().CreateCollection("test", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.EnableSharding(4)
+	if err == nil {
+		t.Fatal("expected error for non-persistent collection, got nil")
+	}
+
+	db, err := NewPersistentDB(t.TempDir(), false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c, err = db.CreateCollection("test", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	for _, shards := range []int{0, 257} {
+		err = c.EnableSharding(shards)
+		if err == nil {
+			t.Fatal("expected error for", shards, "shards, got nil")
+		}
+	}
+}
+
+func TestCollection_EnableSharding_ConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+	tempDir := t.TempDir()
+	db, err := NewPersistentDB(tempDir, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c, err := db.CreateCollection("test", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	// Documents that are added during the redistribution must end up in their
+	// shard
+	const n = 200
+	started := make(chan struct{})
+	errs := make(chan error, 1)
+	go func() {
+		for i := 0; i < n; i++ {
+			if i == n/2 {
+				close(started)
+			}
+			err := c.AddDocument(ctx, Document{ID: strconv.Itoa(i), Embedding: []float32{1, 0, 0}})
+			if err != nil {
+				errs <- err
+				return
+			}
+		}
+		errs <- nil
+	}()
+	<-started
+	err = c.EnableSharding(4)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = <-errs
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	for i := 0; i < n; i++ {
+		if _, err := os.Stat(c.getDocPath(strconv.Itoa(i))); err != nil {
+			t.Fatal("expected document file in its shard, got", err)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package chromem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// structTagKey is the key of the struct tags that [Collection.AddStruct] reads.
+const structTagKey = "chromem"
+
+// AddStruct adds a document to the collection, whose content and metadata are
+// read from the fields of the struct v, based on their struct tags:
+//
+//   - `chromem:"content"`: The field is part of the content. Multiple content
+//     fields are concatenated in the order of their declaration, separated by a
+//     newline. Must be a string.
+//   - `chromem:"metadata"`: The field is stored as metadata, with the field
+//     name as key.
+//   - `chromem:"metadata,key=author"`: The field is stored as metadata with the
+//     given key.
+//
+// Metadata fields can be strings, booleans, numbers, [time.Time] (stored in
+// [time.RFC3339] format, for example for [WithTemporalDecay]) or types that
+// implement [fmt.Stringer], or pointers to them. Metadata fields with a nil
+// pointer are skipped. Fields without tag or with the tag `chromem:"-"`
+// are ignored, and the fields of embedded structs are read as well. The
+// embedding is created from the content with the collection's embedding
+// function.
+//
+//   - id: The ID of the document.
+//   - v: The struct or a pointer to it.
+//
+// Example:
+//
+//	type Article struct {
+//		Title  string    `chromem:"content"`
+//		Body   string    `chromem:"content"`
+//		Author string    `chromem:"metadata,key=author"`
+//		Date   time.Time `chromem:"metadata,key=published"`
+//	}
+//	err := c.AddStruct(ctx, "article-1", article)
+func (c *Collection) AddStruct(ctx context.Context, id string, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return errors.New("struct pointer is nil")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("expected struct or pointer to struct, got %T", v)
+	}
+
+	var contents []string
+	metadata := make(map[string]string)
+	err := readStructFields(rv, &contents, metadata)
+	if err != nil {
+		return err
+	}
+
+	return c.AddDocument(ctx, Document{
+		ID:       id,
+		Metadata: metadata,
+		Content:  strings.Join(contents, "\n"),
+	})
+}
+
+// readStructFields appends the content fields of the struct to contents and
+// adds its metadata fields to metadata, including those of embedded structs.
+func readStructFields(rv reflect.Value, contents *[]string, metadata map[string]string) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag, hasTag := field.Tag.Lookup(structTagKey)
+		if tag == "-" {
+			continue
+		}
+		if !hasTag {
+			if field.Anonymous {
+				fv := rv.Field(i)
+				if fv.Kind() == reflect.Pointer {
+					if fv.IsNil() {
+						continue
+					}
+					fv = fv.Elem()
+				}
+				if fv.Kind() == reflect.Struct {
+					err := readStructFields(fv, contents, metadata)
+					if err != nil {
+						return err
+					}
+				}
+			}
+			continue
+		}
+		if !field.IsExported() {
+			return fmt.Errorf("field %s with %s tag must be exported", field.Name, structTagKey)
+		}
+
+		kind, opts, _ := strings.Cut(tag, ",")
+		switch kind {
+		case "content":
+			if opts != "" {
+				return fmt.Errorf("field %s: content tag doesn't have options, got %q", field.Name, opts)
+			}
+			fv := rv.Field(i)
+			if fv.Kind() != reflect.String {
+				return fmt.Errorf("field %s: content field must be a string, got %s", field.Name, fv.Type())
+			}
+			*contents = append(*contents, fv.String())
+		case "metadata":
+			key := field.Name
+			if opts != "" {
+				k, ok := strings.CutPrefix(opts, "key=")
+				if !ok || k == "" {
+					return fmt.Errorf("field %s: invalid metadata tag options %q", field.Name, opts)
+				}
+				key = k
+			}
+			if _, ok := metadata[key]; ok {
+				return fmt.Errorf("field %s: duplicate metadata key %q", field.Name, key)
+			}
+			// Pointers are dereferenced, so that for example a *time.Time is
+			// stored in the same format as a time.Time and a nil pointer
+			// doesn't panic in its String method.
+			fv := rv.Field(i)
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			value, err := metadataValue(fv)
+			if err != nil {
+				return fmt.Errorf("field %s: %w", field.Name, err)
+			}
+			metadata[key] = value
+		default:
+			return fmt.Errorf("field %s: unknown %s tag %q", field.Name, structTagKey, tag)
+		}
+	}
+	return nil
+}
+
+// metadataValue returns the string representation of a metadata field value.
+func metadataValue(fv reflect.Value) (string, error) {
+	switch v := fv.Interface().(type) {
+	case time.Time:
+		return v.Format(time.RFC3339), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		return fv.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(fv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(fv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(fv.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(fv.Float(), 'g', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(fv.Float(), 'g', -1, 64), nil
+	default:
+		return "", fmt.Errorf("unsupported metadata type %s", fv.Type())
+	}
+}
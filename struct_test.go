@@ -0,0 +1,160 @@
+package chromem
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testStructSource struct {
+	Name string `chromem:"metadata,key=source"`
+}
+
+type testStructStatus int
+
+func (s testStructStatus) String() string {
+	if s == 1 {
+		return "published"
+	}
+	return "draft"
+}
+
+type testStructArticle struct {
+	testStructSource
+
+	Title     string           `chromem:"content"`
+	Body      string           `chromem:"content"`
+	Author    string           `chromem:"metadata,key=author"`
+	Published time.Time        `chromem:"metadata,key=published"`
+	Views     int              `chromem:"metadata"`
+	Rating    float64          `chromem:"metadata,key=rating"`
+	Featured  bool             `chromem:"metadata,key=featured"`
+	Status    testStructStatus `chromem:"metadata,key=status"`
+	Updated   *time.Time       `chromem:"metadata,key=updated"`
+	Archived  *time.Time       `chromem:"metadata,key=archived"`
+	Internal  string           `chromem:"-"`
+	Untagged  string
+}
+
+func TestCollection_AddStruct(t *testing.T) {
+	ctx := context.Background()
+	var embeddedText string
+	embeddingFunc := func(_ context.Context, text string) ([]float32, error) {
+		embeddedText = text
+		return []float32{-0.40824828, 0.40824828, 0.81649655}, nil
+	}
+
+	db := NewDB()
+	c, err := db.CreateCollection("test", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	// Archived is a nil pointer, which is skipped
+	updated := time.Date(2024, 9, 3, 12, 0, 0, 0, time.UTC)
+	article := testStructArticle{
+		testStructSource: testStructSource{Name: "blog"},
+		Title:            "Hello world",
+		Body:             "This is the first post.",
+		Author:           "Jane Doe",
+		Published:        time.Date(2024, 9, 2, 10, 0, 0, 0, time.UTC),
+		Views:            42,
+		Rating:           4.5,
+		Featured:         true,
+		Status:           1,
+		Updated:          &updated,
+		Internal:         "ignored",
+		Untagged:         "ignored",
+	}
+
+	// Pointers and values work
+	for _, v := range []any{article, &article} {
+		err = c.AddStruct(ctx, "1", v)
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+
+		doc, err := c.GetByID(ctx, "1")
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		wantContent := "Hello world\nThis is the first post."
+		if doc.Content != wantContent {
+			t.Fatalf("expected content %q, got %q", wantContent, doc.Content)
+		}
+		if embeddedText != wantContent {
+			t.Fatalf("expected embedded text %q, got %q", wantContent, embeddedText)
+		}
+		wantMetadata := map[string]string{
+			"source":    "blog",
+			"author":    "Jane Doe",
+			"published": "2024-09-02T10:00:00Z",
+			"Views":     "42",
+			"rating":    "4.5",
+			"featured":  "true",
+			"status":    "published",
+			"updated":   "2024-09-03T12:00:00Z",
+		}
+		if !reflect.DeepEqual(wantMetadata, doc.Metadata) {
+			t.Fatal("expected metadata", wantMetadata, "got", doc.Metadata)
+		}
+	}
+}
+
+func TestCollection_AddStruct_Error(t *testing.T) {
+	ctx := context.Background()
+	embeddingFunc := func(_ context.Context, _ string) ([]float32, error) {
+		return []float32{-0.40824828, 0.40824828, 0.81649655}, nil
+	}
+	db := NewDB()
+	c, err := db.CreateCollection("test", nil, embeddingFunc)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	var nilArticle *testStructArticle
+	tt := []struct {
+		name string
+		v    any
+	}{
+		{"Not a struct", "hello world"},
+		{"Nil pointer", nilArticle},
+		{"Non-string content", struct {
+			Content int `chromem:"content"`
+		}{1}},
+		{"Unsupported metadata type", struct {
+			Content string   `chromem:"content"`
+			Tags    []string `chromem:"metadata"`
+		}{"hello", []string{"a"}}},
+		{"Unknown tag", struct {
+			Content string `chromem:"contents"`
+		}{"hello"}},
+		{"Invalid metadata options", struct {
+			Content string `chromem:"content"`
+			Author  string `chromem:"metadata,name=author"`
+		}{"hello", "Jane"}},
+		{"Duplicate metadata key", struct {
+			Content string `chromem:"content"`
+			A       string `chromem:"metadata,key=a"`
+			B       string `chromem:"metadata,key=a"`
+		}{"hello", "a", "b"}},
+		{"Unexported field", struct {
+			content string `chromem:"content"`
+		}{"hello"}},
+		{"No content", struct {
+			Author string `chromem:"metadata"`
+		}{"Jane"}},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := c.AddStruct(ctx, "1", tc.v)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+	if c.Count() != 0 {
+		t.Fatal("expected no documents, got", c.Count())
+	}
+}
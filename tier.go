@@ -0,0 +1,248 @@
+package chromem
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Tier is the storage tier of a document.
+type Tier int
+
+const (
+	// TierHot is the default tier. The document including its embedding is kept
+	// in memory.
+	TierHot Tier = iota
+	// TierWarm documents are kept in memory just like hot ones. The tier is
+	// informational, for example to mark candidates for
+	// [Collection.MoveToColdStorage].
+	TierWarm
+	// TierCold documents are kept in memory without their embedding, which is
+	// only stored on disk. See [Collection.MoveToColdStorage].
+	TierCold
+)
+
+// coldDirName is the name of the subdirectory of the collection directory in
+// which the cold documents are stored.
+const coldDirName = "cold"
+
+// MoveToColdStorage moves the documents with the given IDs to the cold tier.
+// The documents are written to the "cold" subdirectory of the collection
+// directory and their embeddings are removed from memory.
+//
+// Queries only consider cold documents if the hot and warm documents that match
+// the filters are fewer than the requested number of results. In that case, the
+// embeddings of the matching cold documents are read from disk.
+// Adding a document with the ID of a cold document moves it back to the hot tier,
+// unless the added document has [TierCold] as well.
+//
+// If the write-ahead log is enabled (see [Collection.EnableWAL]), the move is
+// logged like adding a document, so that it's not undone by replaying earlier
+// entries.
+//
+// Only works for persistent collections. Documents that are already cold are
+// skipped.
+func (c *Collection) MoveToColdStorage(ids []string) error {
+	if c.persistDirectory == "" {
+		return errors.New("collection is not persistent")
+	}
+
+	end, err := c.beginOp(true)
+	if err != nil {
+		return err
+	}
+	defer end()
+
+	c.documentsLock.Lock()
+	defer c.documentsLock.Unlock()
+
+	for _, id := range ids {
+		if _, ok := c.cold[id]; ok {
+			continue
+		}
+		doc, ok := c.documents[id]
+		if !ok {
+			return fmt.Errorf("document with ID '%v' not found", id)
+		}
+
+		coldDoc := *doc
+		coldDoc.Tier = TierCold
+		err := c.appendWAL(walEntry{Op: walOpAdd, Document: &coldDoc})
+		if err != nil {
+			return err
+		}
+		err = c.persistColdDocument(&coldDoc)
+		if err != nil {
+			return err
+		}
+		c.addColdDocument(&coldDoc)
+	}
+
+	return nil
+}
+
+// addColdDocument adds the document to the in-memory cold documents, without
+// its embedding, and removes it from the hot ones.
+// The caller must hold the documents write lock.
+func (c *Collection) addColdDocument(doc *Document) {
+	coldDoc := *doc
+	coldDoc.Embedding = nil
+	if c.cold == nil {
+		c.cold = make(map[string]*Document)
+	}
+	c.cold[doc.ID] = &coldDoc
+	delete(c.documents, doc.ID)
+	c.unmarkDirty(doc.ID)
+}
+
+// persistColdDocument writes the document including its embedding to the cold
+// directory and removes its hot file. We write the cold file before removing the
+// hot one, so that the document isn't lost if the process stops in between.
+// When loading, the hot file takes precedence.
+func (c *Collection) persistColdDocument(doc *Document) error {
+	err := c.persistDocumentToPath(c.getColdDocPath(doc.ID), doc)
+	if err != nil {
+		return err
+	}
+	return c.removeDocumentFile(doc.ID)
+}
+
+// getColdDocPath generates the path to the cold document file.
+func (c *Collection) getColdDocPath(docID string) string {
+	docPath := filepath.Join(c.persistDirectory, coldDirName, hash2hex(docID))
+	docPath += ".gob"
+	if c.compress {
+		docPath += ".gz"
+	}
+	return docPath
+}
+
+// loadColdDocument reads the cold document including its embedding from disk.
+func (c *Collection) loadColdDocument(docID string) (*Document, error) {
+	doc, err := c.readDocumentFile(c.getColdDocPath(docID))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't load cold document: %w", err)
+	}
+	return doc, nil
+}
+
+// removeColdDocument removes the cold document from memory and disk.
+// The caller must hold the documents write lock.
+func (c *Collection) removeColdDocument(docID string) error {
+	if _, ok := c.cold[docID]; !ok {
+		return nil
+	}
+	delete(c.cold, docID)
+	return c.removeColdDocumentFile(docID)
+}
+
+// removeColdDocumentFile removes the cold document file and its checksum
+// sidecar file (if any) from disk. It's a no-op if the file doesn't exist.
+func (c *Collection) removeColdDocumentFile(docID string) error {
+	err := removeDocumentFileAtPath(c.getColdDocPath(docID))
+	if err != nil {
+		return fmt.Errorf("couldn't remove cold document: %w", err)
+	}
+	return nil
+}
+
+// coldCandidates returns the cold documents that match the filters, including
+// their embeddings which are read from disk.
+// The caller must hold the documents lock.
+func (c *Collection) coldCandidates(where, whereDocument map[string]string) ([]*Document, error) {
+	return c.coldCandidatesFunc(func(doc *Document) bool {
+		return documentMatchesFilters(doc, where, whereDocument)
+	})
+}
+
+// coldCandidatesFunc is like [Collection.coldCandidates], but filters the
+// documents with the given match function.
+// The caller must hold the documents lock.
+func (c *Collection) coldCandidatesFunc(match func(*Document) bool) ([]*Document, error) {
+	if len(c.cold) == 0 {
+		return nil, nil
+	}
+	filteredDocs := filterDocsFunc(c.cold, match)
+	res := make([]*Document, 0, len(filteredDocs))
+	for _, doc := range filteredDocs {
+		loadedDoc, err := c.loadColdDocument(doc.ID)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, loadedDoc)
+	}
+	return res, nil
+}
+
+// allDocuments returns all documents of the collection, with the cold ones
+// including their embeddings, which are read from disk. Cold documents are
+// returned as hot ones.
+// The caller must hold the documents lock.
+func (c *Collection) allDocuments() (map[string]*Document, error) {
+	if len(c.cold) == 0 {
+		return c.documents, nil
+	}
+	res := make(map[string]*Document, len(c.documents)+len(c.cold))
+	for id, doc := range c.documents {
+		res[id] = doc
+	}
+	for id := range c.cold {
+		doc, err := c.loadColdDocument(id)
+		if err != nil {
+			return nil, err
+		}
+		doc.Tier = TierHot
+		res[id] = doc
+	}
+	return res, nil
+}
+
+// loadColdDocuments reads the cold documents with the given file extension from
+// the collection's cold directory, without their embeddings. Documents that
+// also exist in the hot tier are skipped, as they're left over from moving the
+// document back to the hot tier. If removeLeftovers is true, their cold files
+// are removed as well.
+// If a document can't be read, skipDocument is called with its path and the
+// error. If it returns nil, the document is skipped, otherwise loading stops
+// with the returned error.
+func (c *Collection) loadColdDocuments(ext string, removeLeftovers bool, skipDocument func(fPath string, err error) error) error {
+	coldDir := filepath.Join(c.persistDirectory, coldDirName)
+	dirEntries, err := os.ReadDir(coldDir)
+	if err != nil {
+		return fmt.Errorf("couldn't read cold directory: %w", err)
+	}
+	// The map marks the collection as having cold files, even if all of them
+	// are left over.
+	if c.cold == nil {
+		c.cold = make(map[string]*Document)
+	}
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || !strings.HasSuffix(dirEntry.Name(), ext) {
+			continue
+		}
+		fPath := filepath.Join(coldDir, dirEntry.Name())
+		d, err := c.readDocumentFile(fPath)
+		if err != nil {
+			err = skipDocument(fPath, fmt.Errorf("couldn't read cold document: %w", err))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if _, ok := c.documents[d.ID]; ok {
+			if removeLeftovers {
+				err = c.removeColdDocumentFile(d.ID)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		d.Embedding = nil
+		c.cold[d.ID] = d
+	}
+
+	return nil
+}
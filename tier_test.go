@@ -0,0 +1,392 @@
+package chromem
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollection_MoveToColdStorage(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir()
+
+	db, err := NewPersistentDB(path, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c, err := db.CreateCollection("test", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.AddDocuments(ctx, []Document{
+		{ID: "1", Embedding: []float32{1, 0, 0}, Content: "hello world"},
+		{ID: "2", Embedding: []float32{0, 1, 0}, Content: "hallo welt"},
+		{ID: "3", Embedding: []float32{0, 0, 1}, Content: "bonjour le monde"},
+	}, 1)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	err = c.MoveToColdStorage([]string{"3"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if c.Count() != 3 {
+		t.Fatal("expected 3 documents, got", c.Count())
+	}
+
+	// The cold document is only scanned if there aren't enough hot ones
+	if est := c.QueryDryRun(ctx, "hello", 2); est.DocumentsToScan != 2 {
+		t.Fatal("expected 2 documents to scan, got", est.DocumentsToScan)
+	}
+	if est := c.QueryDryRun(ctx, "hello", 3); est.DocumentsToScan != 3 {
+		t.Fatal("expected 3 documents to scan, got", est.DocumentsToScan)
+	}
+	// Cold documents are filtered like hot ones
+	for _, tc := range []struct {
+		contains string
+		want     int
+	}{
+		{"monde", 1},
+		{"hello", 1},
+	} {
+		est := c.QueryWithOptionsDryRun(ctx, QueryOptions{
+			QueryText:     "hello",
+			NResults:      2,
+			WhereDocument: map[string]string{"$contains": tc.contains},
+		})
+		if est.DocumentsToScan != tc.want {
+			t.Fatal("expected", tc.want, "documents to scan for", tc.contains, "got", est.DocumentsToScan)
+		}
+	}
+	if c.cold["3"] == nil || c.cold["3"].Embedding != nil {
+		t.Fatal("expected cold document without embedding in memory")
+	}
+	if _, err := os.Stat(c.getColdDocPath("3")); err != nil {
+		t.Fatal("expected cold document file to exist, got", err)
+	}
+	if _, err := os.Stat(c.getDocPath("3")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("expected hot document file to not exist, got", err)
+	}
+
+	// With enough hot documents, cold ones aren't considered
+	res, err := c.QueryEmbedding(ctx, []float32{0, 0.1, 1}, 1, nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if len(res) != 1 || res[0].ID != "2" {
+		t.Fatal("expected only result to be hot document 2, got", res)
+	}
+
+	// Otherwise the cold document is the best match
+	checkQuery := func(t *testing.T, c *Collection) {
+		res, err := c.QueryEmbedding(ctx, []float32{0, 0.1, 1}, 3, nil, nil)
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		if len(res) != 3 {
+			t.Fatal("expected 3 results, got", len(res))
+		}
+		if res[0].ID != "3" {
+			t.Fatal("expected cold document 3 as best match, got", res[0].ID)
+		}
+		if len(res[0].Embedding) != 3 {
+			t.Fatal("expected result to contain the embedding, got", res[0].Embedding)
+		}
+	}
+	checkQuery(t, c)
+
+	doc, err := c.GetByID(ctx, "3")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if doc.Tier != TierCold || len(doc.Embedding) != 3 {
+		t.Fatal("expected cold document with embedding, got", doc)
+	}
+
+	// Cold documents stay cold when loading the DB
+	db, err = NewPersistentDB(path, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c = db.GetCollection("test", nil)
+	if c.Count() != 3 || c.cold["3"] == nil {
+		t.Fatal("expected 3 documents with a cold one, got", c.Count())
+	}
+	checkQuery(t, c)
+
+	// Adding the document again moves it back to the hot tier
+	err = c.AddDocument(ctx, Document{ID: "3", Embedding: []float32{0, 0, 1}, Content: "bonjour le monde"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if len(c.cold) != 0 || c.Count() != 3 {
+		t.Fatal("expected no cold documents")
+	}
+	if _, err := os.Stat(c.getColdDocPath("3")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("expected cold document file to not exist, got", err)
+	}
+
+	// Only persistent collections are supported
+	c, err = NewDB().CreateCollection("test", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.MoveToColdStorage([]string{"1"})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestCollection_MoveToColdStorage_DeferredWrite(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir()
+
+	db, err := NewPersistentDB(path, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c, err := db.CreateCollection("test", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.AddDocuments(ctx, []Document{
+		{ID: "1", Embedding: []float32{1, 0, 0}, Content: "hello world"},
+		{ID: "2", Embedding: []float32{0, 1, 0}, Content: "hallo welt"},
+	}, 1)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.MoveToColdStorage([]string{"2"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.EnableAutoSave(time.Hour)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	defer c.DisableAutoSave()
+
+	// Until the hot file is written, the cold file is kept, so that the document
+	// isn't lost.
+	err = c.AddDocument(ctx, Document{ID: "2", Embedding: []float32{0, 1, 0}, Content: "hallo welt"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if _, err := os.Stat(c.getColdDocPath("2")); err != nil {
+		t.Fatal("expected cold document file to exist, got", err)
+	}
+	db2, err := NewPersistentDB(path, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if c2 := db2.GetCollection("test", nil); c2.Count() != 2 || c2.cold["2"] == nil {
+		t.Fatal("expected document 2 to be loaded from the cold tier")
+	}
+
+	// Flushing writes the hot file and removes the cold one
+	err = c.Flush(ctx)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if _, err := os.Stat(c.getColdDocPath("2")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("expected cold document file to not exist, got", err)
+	}
+	if _, err := os.Stat(c.getDocPath("2")); err != nil {
+		t.Fatal("expected hot document file to exist, got", err)
+	}
+}
+
+func TestCollection_MoveToColdStorage_PersistOptions(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir()
+	abstract := strings.Repeat("a", 2*metadataCompressionThreshold)
+
+	db, err := NewPersistentDB(path, false, WithChecksumVerification())
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c, err := db.CreateCollection("test", nil, nil, WithMetadataCompression())
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.AddDocuments(ctx, []Document{
+		{ID: "1", Embedding: []float32{1, 0.1, 0}, Content: "hello world"},
+		{ID: "2", Embedding: []float32{1, 0.2, 0}, Content: "hallo welt", Metadata: map[string]string{"abstract": abstract}},
+	}, 1)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.EnableDeltaEncoding("1")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.MoveToColdStorage([]string{"2"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	// Cold files are written like hot ones
+	coldPath := c.getColdDocPath("2")
+	if _, err := os.Stat(checksumPath(coldPath)); err != nil {
+		t.Fatal("expected checksum file of cold document to exist, got", err)
+	}
+	pd := &persistedDocument{}
+	err = readFromFile(coldPath, pd, "")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if len(pd.EmbeddingDelta) == 0 || len(pd.CompressedMetadataKeys) != 1 {
+		t.Fatal("expected delta-encoded cold document with compressed metadata, got", pd)
+	}
+
+	// Re-encoding with another reference includes cold documents
+	err = c.EnableDeltaEncoding("")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	wantEmbedding := normalizeVector([]float32{1, 0.2, 0})
+	db, err = NewPersistentDB(path, false, WithChecksumVerification())
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c = db.GetCollection("test", nil)
+	doc, err := c.GetByID(ctx, "2")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if doc.Tier != TierCold || !slices.Equal(wantEmbedding, doc.Embedding) || doc.Metadata["abstract"] != abstract {
+		t.Fatal("expected cold document with original embedding and metadata, got", doc)
+	}
+
+	// Corrupted cold files are detected
+	b, err := os.ReadFile(coldPath)
+	if err != nil {
+		t.Fatal("couldn't read cold document file:", err)
+	}
+	b[len(b)/2] ^= 0xff
+	err = os.WriteFile(coldPath, b, 0o600)
+	if err != nil {
+		t.Fatal("couldn't write cold document file:", err)
+	}
+	_, err = NewPersistentDB(path, false, WithChecksumVerification())
+	if !errors.As(err, &ErrChecksumMismatch{}) {
+		t.Fatal("expected ErrChecksumMismatch, got", err)
+	}
+}
+
+func TestCollection_MoveToColdStorage_WAL(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir()
+
+	db, err := NewPersistentDB(path, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c, err := db.CreateCollection("test", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.EnableWAL("")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.AddDocuments(ctx, []Document{
+		{ID: "1", Embedding: []float32{1, 0, 0}, Content: "hello world"},
+		{ID: "2", Embedding: []float32{0, 1, 0}, Content: "hallo welt"},
+		{ID: "3", Embedding: []float32{0, 0, 1}, Content: "bonjour le monde"},
+	}, 1)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.MoveToColdStorage([]string{"1", "2"})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.Delete(ctx, nil, nil, "2")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	// Replaying the WAL when loading keeps the moved document cold and doesn't
+	// bring back the deleted one
+	db, err = NewPersistentDB(path, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c = db.GetCollection("test", nil)
+	if c.Count() != 2 {
+		t.Fatal("expected 2 documents, got", c.Count())
+	}
+	if c.cold["1"] == nil || c.documents["1"] != nil {
+		t.Fatal("expected document 1 to be cold")
+	}
+	if _, err := os.Stat(c.getColdDocPath("1")); err != nil {
+		t.Fatal("expected cold document file to exist, got", err)
+	}
+	if _, err := os.Stat(c.getDocPath("1")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("expected hot document file to not exist, got", err)
+	}
+}
+
+func TestCollection_AddDocument_Cold(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir()
+
+	db, err := NewPersistentDB(path, false)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	c, err := db.CreateCollection("test", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.AddDocuments(ctx, []Document{
+		{ID: "1", Embedding: []float32{1, 0, 0}, Content: "hello world"},
+		{ID: "2", Embedding: []float32{0, 1, 0}, Content: "hallo welt", Tier: TierCold},
+	}, 1)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	// The document is added to the cold tier right away
+	if c.cold["2"] == nil || c.cold["2"].Embedding != nil || c.documents["2"] != nil {
+		t.Fatal("expected cold document without embedding in memory")
+	}
+	if _, err := os.Stat(c.getColdDocPath("2")); err != nil {
+		t.Fatal("expected cold document file to exist, got", err)
+	}
+	doc, err := c.GetByID(ctx, "2")
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if doc.Tier != TierCold || len(doc.Embedding) != 3 {
+		t.Fatal("expected cold document with embedding, got", doc)
+	}
+
+	// Adding a hot document as cold moves it to the cold tier
+	err = c.AddDocument(ctx, Document{ID: "1", Embedding: []float32{1, 0, 0}, Content: "hello world", Tier: TierCold})
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if c.cold["1"] == nil || c.documents["1"] != nil || c.Count() != 2 {
+		t.Fatal("expected document 1 to be cold")
+	}
+	if _, err := os.Stat(c.getDocPath("1")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("expected hot document file to not exist, got", err)
+	}
+
+	// Only persistent collections are supported
+	c, err = NewDB().CreateCollection("test", nil, nil)
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	err = c.AddDocument(ctx, Document{ID: "1", Embedding: []float32{1, 0, 0}, Tier: TierCold})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
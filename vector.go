@@ -40,6 +40,15 @@ func normalizeVector(v []float32) []float32 {
 	return res
 }
 
+// vectorNorm calculates the euclidean norm (magnitude) of the vector.
+func vectorNorm(v []float32) float64 {
+	var sqSum float64
+	for _, val := range v {
+		sqSum += float64(val) * float64(val)
+	}
+	return math.Sqrt(sqSum)
+}
+
 // subtractVector subtracts vector b from vector a in place.
 func subtractVector(a, b []float32) []float32 {
 	res := make([]float32, len(a))
@@ -53,10 +62,44 @@ func subtractVector(a, b []float32) []float32 {
 
 // isNormalized checks if the vector is normalized.
 func isNormalized(v []float32) bool {
-	var sqSum float64
+	return math.Abs(vectorNorm(v)-1) < isNormalizedPrecisionTolerance
+}
+
+// sparseDotProduct calculates the dot product between two sparse vectors.
+// Like [dotProduct], it's the same as cosine similarity for normalized vectors.
+func sparseDotProduct(a, b map[uint32]float32) float32 {
+	// Iterate over the smaller map
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+
+	var dotProduct float32
+	for k, va := range a {
+		if vb, ok := b[k]; ok {
+			dotProduct += va * vb
+		}
+	}
+
+	return dotProduct
+}
+
+// normalizeSparseVector returns a normalized copy of the sparse vector.
+// If the vector's norm is 0, it returns a plain copy.
+func normalizeSparseVector(v map[uint32]float32) map[uint32]float32 {
+	var norm float32
 	for _, val := range v {
-		sqSum += float64(val) * float64(val)
+		norm += val * val
+	}
+	norm = float32(math.Sqrt(float64(norm)))
+
+	res := make(map[uint32]float32, len(v))
+	for k, val := range v {
+		if norm == 0 {
+			res[k] = val
+		} else {
+			res[k] = val / norm
+		}
 	}
-	magnitude := math.Sqrt(sqSum)
-	return math.Abs(magnitude-1) < isNormalizedPrecisionTolerance
+
+	return res
 }
@@ -0,0 +1,267 @@
+package chromem
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// walFileExt is the extension of the default write-ahead log file, which is
+// placed alongside the collection directory.
+const walFileExt = ".wal"
+
+// maxWALRecordSize is the maximum size of an encoded WAL entry. It protects
+// against huge allocations when reading a corrupted length prefix.
+const maxWALRecordSize = 256 << 20 // 256 MiB
+
+// walHeaderSize is the size of the header of each WAL record: the length of the
+// encoded entry and its CRC32 checksum, both as big-endian uint32.
+const walHeaderSize = 8
+
+type walOp uint8
+
+const (
+	walOpAdd walOp = iota + 1
+	walOpDelete
+)
+
+// walEntry is a single mutation in a collection's write-ahead log.
+type walEntry struct {
+	Op walOp
+	// Set for add operations
+	Document *Document
+	// Set for delete operations
+	ID string
+}
+
+// EnableWAL enables the write-ahead log (WAL) for the collection. From now on,
+// each added or deleted document is first appended to the WAL and only then
+// written to (or removed from) the main document files. If the process stops
+// in between, the WAL is replayed when the DB is loaded with [NewPersistentDB].
+//
+// If walPath is empty, the WAL is placed alongside the collection directory,
+// with the same name and a ".wal" extension. The path is stored in the
+// collection metadata, so it only needs to be enabled once.
+//
+// Only works for persistent collections.
+func (c *Collection) EnableWAL(walPath string) error {
+	if c.persistDirectory == "" {
+		return errors.New("collection is not persistent")
+	}
+
+	end, err := c.beginOp(true)
+	if err != nil {
+		return err
+	}
+	defer end()
+
+	c.walLock.Lock()
+	defer c.walLock.Unlock()
+
+	// The directory can only be read under the lock, see [DB.MovePersistenceDir]
+	if walPath == "" {
+		walPath = c.persistDirectory + walFileExt
+	}
+
+	// Make sure the file can be created, so that adding documents doesn't
+	// fail later.
+	f, err := os.OpenFile(walPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("couldn't open WAL file: %w", err)
+	}
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("couldn't close WAL file: %w", err)
+	}
+
+	c.walPath = walPath
+	err = c.persistMetadata()
+	if err != nil {
+		c.walPath = ""
+		return fmt.Errorf("couldn't persist collection metadata: %w", err)
+	}
+	return nil
+}
+
+// CheckpointWAL applies all entries of the write-ahead log to the main document
+// files and then truncates the log. It's a no-op if the WAL isn't enabled.
+func (c *Collection) CheckpointWAL() error {
+	end, err := c.beginOp(true)
+	if err != nil {
+		return err
+	}
+	defer end()
+
+	// The documents lock is needed for writing the document files
+	c.documentsLock.Lock()
+	defer c.documentsLock.Unlock()
+	c.walLock.Lock()
+	defer c.walLock.Unlock()
+
+	if c.walPath == "" {
+		return nil
+	}
+	return c.replayWAL(c.walPath, false)
+}
+
+// appendWAL appends the entry to the write-ahead log and syncs it to disk.
+// It's a no-op if the WAL isn't enabled.
+func (c *Collection) appendWAL(entry walEntry) error {
+	c.walLock.Lock()
+	defer c.walLock.Unlock()
+
+	if c.walPath == "" {
+		return nil
+	}
+
+	buf := &bytes.Buffer{}
+	// Reserve space for the header
+	buf.Write(make([]byte, walHeaderSize))
+	err := gob.NewEncoder(buf).Encode(entry)
+	if err != nil {
+		return fmt.Errorf("couldn't encode WAL entry: %w", err)
+	}
+	record := buf.Bytes()
+	payload := record[walHeaderSize:]
+	if len(payload) > maxWALRecordSize {
+		return fmt.Errorf("WAL entry is too large: %d bytes", len(payload))
+	}
+	binary.BigEndian.PutUint32(record, uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:], crc32.ChecksumIEEE(payload))
+
+	f, err := os.OpenFile(c.walPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("couldn't open WAL file: %w", err)
+	}
+	defer f.Close()
+	_, err = f.Write(record)
+	if err != nil {
+		return fmt.Errorf("couldn't write WAL entry: %w", err)
+	}
+	err = f.Sync()
+	if err != nil {
+		return fmt.Errorf("couldn't sync WAL file: %w", err)
+	}
+	return nil
+}
+
+// replayWAL applies all entries of the write-ahead log at the given path to the
+// main document files and, if toMemory is true, to the in-memory documents as
+// well. Then it truncates the log. A torn or corrupted record (for example from
+// a crash during the append) is treated as the end of the log, so it and any
+// following bytes are dropped with the truncation.
+// The caller must hold the documents and WAL locks if toMemory is false, or
+// make sure there's no concurrent access.
+func (c *Collection) replayWAL(walPath string, toMemory bool) error {
+	entries, err := readWAL(walPath)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		switch entry.Op {
+		case walOpAdd:
+			if entry.Document == nil {
+				return errors.New("WAL add entry without document")
+			}
+			// Cold documents are logged with their embedding, when they're
+			// added to or moved to the cold tier. In memory, they're only
+			// removed from the hot tier here, as the cold documents are read
+			// from their files after the replay.
+			if entry.Document.Tier == TierCold {
+				if toMemory {
+					c.documentsLock.Lock()
+					delete(c.documents, entry.Document.ID)
+					c.documentsLock.Unlock()
+				}
+				err = c.persistColdDocument(entry.Document)
+				break
+			}
+			if toMemory {
+				c.documentsLock.Lock()
+				c.documents[entry.Document.ID] = entry.Document
+				c.documentsLock.Unlock()
+			}
+			err = c.persistDocument(entry.Document)
+		case walOpDelete:
+			if toMemory {
+				c.documentsLock.Lock()
+				delete(c.documents, entry.ID)
+				c.documentsLock.Unlock()
+			}
+			err = c.removeDocumentFile(entry.ID)
+			if err == nil {
+				err = c.removeColdDocumentFile(entry.ID)
+			}
+		default:
+			return fmt.Errorf("unknown WAL operation: %d", entry.Op)
+		}
+		if err != nil {
+			return fmt.Errorf("couldn't apply WAL entry: %w", err)
+		}
+	}
+
+	err = os.Truncate(walPath, 0)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("couldn't truncate WAL file: %w", err)
+	}
+	return nil
+}
+
+// readWAL reads all valid entries from the write-ahead log at the given path.
+// Reading stops at the first record that is incomplete, too large, has a
+// checksum mismatch or can't be decoded. A non-existing file is treated as an
+// empty log.
+func readWAL(walPath string) ([]walEntry, error) {
+	f, err := os.Open(walPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("couldn't open WAL file: %w", err)
+	}
+	defer f.Close()
+
+	var entries []walEntry
+	r := bufio.NewReader(f)
+	header := make([]byte, walHeaderSize)
+	for {
+		_, err := io.ReadFull(r, header)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return nil, fmt.Errorf("couldn't read WAL file: %w", err)
+		}
+		size := binary.BigEndian.Uint32(header)
+		if size > maxWALRecordSize {
+			break
+		}
+		record := make([]byte, size)
+		_, err = io.ReadFull(r, record)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return nil, fmt.Errorf("couldn't read WAL file: %w", err)
+		}
+		if crc32.ChecksumIEEE(record) != binary.BigEndian.Uint32(header[4:]) {
+			break
+		}
+
+		var entry walEntry
+		err = gob.NewDecoder(bytes.NewReader(record)).Decode(&entry)
+		if err != nil {
+			break
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}